@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrMalformedClaims is returned for correctly signed tokens whose claims
+// are missing or have the wrong shape.
+var ErrMalformedClaims = errors.New("malformed token claims")
+
+// Claims are the claims of an access token. Subject and UserID both hold
+// the user's ID; userId is kept for clients that read it directly.
+type Claims struct {
+	UserID    string `json:"userId"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	MFA       bool   `json:"mfa,omitempty"`
+	jwt.StandardClaims
+}
+
+// Valid is called by the jwt library after the signature is verified. It
+// requires every claim the API relies on.
+func (c *Claims) Valid() error {
+	if c.Subject == "" || c.Id == "" || c.IssuedAt == 0 || c.ExpiresAt == 0 || c.Role == "" {
+		return ErrMalformedClaims
+	}
+	if c.UserID != c.Subject || !primitive.IsValidObjectID(c.Subject) {
+		return ErrMalformedClaims
+	}
+	if c.SessionID != "" && !primitive.IsValidObjectID(c.SessionID) {
+		return ErrMalformedClaims
+	}
+
+	if err := c.StandardClaims.Valid(); err != nil {
+		return ErrInvalidToken
+	}
+	if !c.VerifyIssuer(Issuer, true) || !c.VerifyAudience(Audience, true) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func (c *Claims) IssuedAtTime() time.Time {
+	return time.Unix(c.IssuedAt, 0)
+}
+
+func (c *Claims) ExpiresAtTime() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Key is a JWT signing or verification key identified by its kid.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Public  crypto.PublicKey
+	Private crypto.PrivateKey
+}
+
+// KeySet holds every key that tokens may still be signed with plus the one
+// key new tokens are signed with. Rotating means adding a new key, making
+// it active, and removing the old one once its tokens have expired.
+type KeySet struct {
+	mu     sync.RWMutex
+	keys   map[string]*Key
+	active string
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{keys: map[string]*Key{}}
+}
+
+func (ks *KeySet) Add(k *Key) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[k.ID] = k
+}
+
+func (ks *KeySet) SetActive(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	k, ok := ks.keys[kid]
+	if !ok {
+		return fmt.Errorf("auth: unknown key %q", kid)
+	}
+	if k.Private == nil {
+		return fmt.Errorf("auth: key %q has no private key and cannot sign", kid)
+	}
+	ks.active = kid
+	return nil
+}
+
+func (ks *KeySet) Active() (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	k, ok := ks.keys[ks.active]
+	if !ok {
+		return nil, errors.New("auth: no active signing key")
+	}
+	return k, nil
+}
+
+func (ks *KeySet) Get(kid string) (*Key, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+// LoadKeyDir reads every *.pem file in dir. The file name without extension
+// is the kid. Files may hold a PKCS#8 or PKCS#1 private key, or a PKIX
+// public key for a retired key that is only used for verification.
+//
+// Keys can be generated with:
+//
+//	openssl genpkey -algorithm ed25519 -out keys/2026-10.pem
+//	openssl genpkey -algorithm rsa -pkeyopt rsa_keygen_bits:2048 -out keys/2026-10.pem
+func LoadKeyDir(dir string) (*KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("auth: no .pem keys found in %s", dir)
+	}
+	sort.Strings(files)
+
+	ks := NewKeySet()
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		key, err := parsePEMKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("auth: %s: %w", f, err)
+		}
+		ks.Add(key)
+	}
+	return ks, nil
+}
+
+func parsePEMKey(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, Public: &k.PublicKey, Private: k}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Method: SigningMethodEdDSA, Public: k.Public(), Private: k}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Method: SigningMethodEdDSA, Public: k}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", parsed)
+}
+
+// GenerateEd25519Key returns a fresh in-memory key. It is only meant for
+// local development where no key directory is configured.
+func GenerateEd25519Key(kid string) (*Key, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: kid, Method: SigningMethodEdDSA, Public: pub, Private: priv}, nil
+}
+
+// JWKS returns the public keys in JSON Web Key Set format.
+func (ks *KeySet) JWKS() map[string]interface{} {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]map[string]string, 0, len(kids))
+	for _, kid := range kids {
+		k := ks.keys[kid]
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": k.ID,
+				"use": "sig",
+				"alg": k.Method.Alg(),
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"kid": k.ID,
+				"use": "sig",
+				"alg": k.Method.Alg(),
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return map[string]interface{}{"keys": keys}
+}
@@ -0,0 +1,54 @@
+package blob
+
+import (
+	"context"
+	"ecommerce/config"
+	"log"
+)
+
+// BlobStore keeps uploaded files such as product images. Keys are
+// slash-separated paths chosen by the caller.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// URL is where clients can fetch the blob.
+	URL(key string) string
+}
+
+var Default BlobStore = NewLocal("uploads", "/uploads")
+
+// Init selects the store from BLOB_BACKEND: "local" (default) writes to
+// BLOB_LOCAL_DIR and is served by the app under /uploads; "s3" uses any
+// S3-compatible service. BLOB_PUBLIC_URL overrides the base of the URLs
+// handed out, e.g. for a CDN.
+func Init() {
+	switch config.GetEnv("BLOB_BACKEND", "local") {
+	case "s3":
+		s3, err := NewS3(
+			config.GetEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			config.GetEnv("S3_REGION", "us-east-1"),
+			config.GetEnv("S3_BUCKET", ""),
+			config.GetEnv("S3_ACCESS_KEY_ID", ""),
+			config.GetEnv("S3_SECRET_ACCESS_KEY", ""),
+			config.GetEnv("BLOB_PUBLIC_URL", ""),
+		)
+		if err != nil {
+			log.Fatal("❌ Failed to configure S3 blob store:", err)
+		}
+		Default = s3
+	default:
+		Default = NewLocal(config.GetEnv("BLOB_LOCAL_DIR", "uploads"), config.GetEnv("BLOB_PUBLIC_URL", "/uploads"))
+	}
+}
+
+func Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return Default.Put(ctx, key, data, contentType)
+}
+
+func Delete(ctx context.Context, key string) error {
+	return Default.Delete(ctx, key)
+}
+
+func URL(key string) string {
+	return Default.URL(key)
+}
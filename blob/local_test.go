@@ -0,0 +1,42 @@
+package blob
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorePath(t *testing.T) {
+	s := NewLocal("/srv/uploads", "/uploads")
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"products/a/original.png", "/srv/uploads/products/a/original.png"},
+		{"products/../b.png", "/srv/uploads/b.png"},
+		{"../secret", ""},
+		{"../../etc/passwd", ""},
+		{"products/../../secret", ""},
+		{"/etc/passwd", ""},
+		{"..", ""},
+		{".", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := s.path(tt.key)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("path(%q) = %q, want error", tt.key, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q): %v", tt.key, err)
+			}
+			if want := filepath.FromSlash(tt.want); got != want {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package bootstrap
+
+import (
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/passwd"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// EnsureAdmin makes sure a user with the given email exists and has the
+// admin role. An existing user is promoted and keeps their password; a new
+// user is created with the given password.
+func EnsureAdmin(ctx context.Context, name, email, password string) (models.User, error) {
+	if email == "" {
+		return models.User{}, errors.New("admin email is required")
+	}
+
+	var user models.User
+	err := database.UserCollection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return models.User{}, err
+	}
+
+	if err == mongo.ErrNoDocuments {
+		if password == "" {
+			return models.User{}, errors.New("admin password is required to create a new user")
+		}
+		if name == "" {
+			name = "Administrator"
+		}
+
+		if problems := passwd.PolicyFromEnv().Validate(password, email, name); len(problems) > 0 {
+			return models.User{}, fmt.Errorf("admin password rejected: %s", strings.Join(problems, "; "))
+		}
+
+		hashed, err := passwd.Hash(password)
+		if err != nil {
+			return models.User{}, err
+		}
+
+		now := time.Now()
+		user = models.User{
+			ID:        primitive.NewObjectID(),
+			Name:      name,
+			Email:     email,
+			Password:  hashed,
+			Role:      models.RoleAdmin,
+			CreatedAt: now,
+
+			// The operator supplied this address directly.
+			EmailVerified:   true,
+			EmailVerifiedAt: &now,
+		}
+		if _, err := database.UserCollection.InsertOne(ctx, user); err != nil {
+			return models.User{}, err
+		}
+
+		return user, recordBootstrap(ctx, user.ID, "")
+	}
+
+	if user.Role == models.RoleAdmin {
+		return user, nil
+	}
+
+	previous := user.Role
+	_, err = database.UserCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"role": models.RoleAdmin}})
+	if err != nil {
+		return models.User{}, err
+	}
+	user.Role = models.RoleAdmin
+
+	return user, recordBootstrap(ctx, user.ID, previous)
+}
+
+// AdminFromEnv creates the first admin from ADMIN_EMAIL, ADMIN_PASSWORD and
+// ADMIN_NAME. It does nothing once any admin exists, so the variables can be
+// left in place after the first deploy.
+func AdminFromEnv() {
+	email := config.GetEnv("ADMIN_EMAIL", "")
+	if email == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := database.UserCollection.CountDocuments(ctx, bson.M{"role": models.RoleAdmin})
+	if err != nil {
+		log.Println("⚠️  Failed to check for existing admin:", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	user, err := EnsureAdmin(ctx, config.GetEnv("ADMIN_NAME", ""), email, config.GetEnv("ADMIN_PASSWORD", ""))
+	if err != nil {
+		log.Println("❌ Failed to bootstrap admin:", err)
+		return
+	}
+
+	log.Printf("✅ Bootstrapped admin %s", user.Email)
+}
+
+func recordBootstrap(ctx context.Context, userID primitive.ObjectID, previousRole string) error {
+	_, err := database.RoleAuditCollection.InsertOne(ctx, models.RoleAudit{
+		ID:           primitive.NewObjectID(),
+		UserID:       userID,
+		Action:       models.RoleActionBootstrap,
+		PreviousRole: previousRole,
+		Role:         models.RoleAdmin,
+		CreatedAt:    time.Now(),
+	})
+	return err
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"ecommerce/bootstrap"
+	"ecommerce/config"
+	"ecommerce/database"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// The password of a new admin is read from ADMIN_PASSWORD or, with
+// -password-stdin, from the first line of standard input, so it does not
+// end up in the shell history or the process list:
+//
+//	echo "$PASSWORD" | go run ./cmd/createadmin -email admin@example.com -password-stdin
+func main() {
+	name := flag.String("name", "", "display name for a newly created admin")
+	email := flag.String("email", "", "email of the user to create or promote")
+	passwordStdin := flag.Bool("password-stdin", false, "read the password for a newly created admin from stdin")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("❌ -email is required")
+	}
+
+	config.LoadEnv()
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatal("❌ Failed to read password from stdin: ", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	database.ConnectMongo()
+	database.InitCollections()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := bootstrap.EnsureAdmin(ctx, *name, *email, password)
+	if err != nil {
+		log.Fatal("❌ Failed to create admin: ", err)
+	}
+
+	log.Printf("✅ %s is now an admin", user.Email)
+}
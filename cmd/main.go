@@ -1,9 +1,17 @@
 package main
 
 import (
+	"ecommerce/auth"
+	"ecommerce/blob"
+	"ecommerce/bootstrap"
 	"ecommerce/config"
 	"ecommerce/database"
+	"ecommerce/mailer"
+	"ecommerce/oidc"
+	"ecommerce/rbac"
+	"ecommerce/revocation"
 	"ecommerce/routes"
+	"ecommerce/search"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,8 +20,18 @@ func main() {
 
 	config.LoadEnv()
 
+	auth.Init()
+
 	database.ConnectMongo()
 	database.InitCollections()
+	database.EnsureIndexes()
+	revocation.Init()
+	rbac.Init()
+	search.Init()
+	bootstrap.AdminFromEnv()
+	mailer.Init()
+	blob.Init()
+	oidc.Init()
 
 	r := gin.Default()
 	r.SetTrustedProxies(nil)
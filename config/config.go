@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -21,3 +23,42 @@ func GetEnv(key, fallback string) string {
 	}
 	return val
 }
+
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		log.Printf("⚠️  Invalid duration for %s: %q, using %s", key, val, fallback)
+		return fallback
+	}
+	return d
+}
+
+func GetEnvBool(key string, fallback bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		log.Printf("⚠️  Invalid boolean for %s: %q, using %t", key, val, fallback)
+		return fallback
+	}
+	return b
+}
+
+func GetEnvInt(key string, fallback int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		log.Printf("⚠️  Invalid integer for %s: %q, using %d", key, val, fallback)
+		return fallback
+	}
+	return n
+}
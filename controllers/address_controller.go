@@ -0,0 +1,341 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxAddressesPerUser = 20
+
+var (
+	postalCodePattern = regexp.MustCompile(`^[1-9][0-9]{4}$`)
+	phonePattern      = regexp.MustCompile(`^(\+62|62|0)8[0-9]{7,11}$`)
+)
+
+var errAddressRequired = errors.New("no address given and no default address set")
+
+// addressInput is the body of the create and update endpoints. Fields left
+// out keep their current value on update.
+type addressInput struct {
+	Label             *string `json:"label"`
+	RecipientName     *string `json:"recipientName"`
+	Phone             *string `json:"phone"`
+	Street            *string `json:"street"`
+	District          *string `json:"district"`
+	City              *string `json:"city"`
+	Province          *string `json:"province"`
+	PostalCode        *string `json:"postalCode"`
+	Notes             *string `json:"notes"`
+	IsDefaultShipping *bool   `json:"isDefaultShipping"`
+	IsDefaultBilling  *bool   `json:"isDefaultBilling"`
+}
+
+func (in addressInput) apply(a *models.Address) {
+	set := func(dst *string, src *string) {
+		if src != nil {
+			*dst = strings.TrimSpace(*src)
+		}
+	}
+	set(&a.Label, in.Label)
+	set(&a.RecipientName, in.RecipientName)
+	set(&a.Phone, in.Phone)
+	set(&a.Street, in.Street)
+	set(&a.District, in.District)
+	set(&a.City, in.City)
+	set(&a.Province, in.Province)
+	set(&a.PostalCode, in.PostalCode)
+	set(&a.Notes, in.Notes)
+	if in.IsDefaultShipping != nil {
+		a.IsDefaultShipping = *in.IsDefaultShipping
+	}
+	if in.IsDefaultBilling != nil {
+		a.IsDefaultBilling = *in.IsDefaultBilling
+	}
+}
+
+// validateAddress returns the problems with each field and normalizes the
+// phone number to +62 form and the province to its canonical spelling.
+func validateAddress(a *models.Address) map[string][]string {
+	fields := map[string][]string{}
+	required := map[string]string{
+		"recipientName": a.RecipientName,
+		"phone":         a.Phone,
+		"street":        a.Street,
+		"district":      a.District,
+		"city":          a.City,
+		"province":      a.Province,
+		"postalCode":    a.PostalCode,
+	}
+	for field, value := range required {
+		if value == "" {
+			fields[field] = append(fields[field], "Required")
+		}
+	}
+
+	if a.Phone != "" {
+		phone := strings.NewReplacer(" ", "", "-", "").Replace(a.Phone)
+		if !phonePattern.MatchString(phone) {
+			fields["phone"] = append(fields["phone"], "Must be an Indonesian mobile number such as 0812xxxxxxxx or +62812xxxxxxxx")
+		} else {
+			a.Phone = "+62" + strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(phone, "+62"), "62"), "0")
+		}
+	}
+	if a.PostalCode != "" && !postalCodePattern.MatchString(a.PostalCode) {
+		fields["postalCode"] = append(fields["postalCode"], "Must be a 5 digit postal code")
+	}
+	if a.Province != "" {
+		known := false
+		for _, p := range models.Provinces {
+			if strings.EqualFold(p, a.Province) {
+				a.Province = p
+				known = true
+				break
+			}
+		}
+		if !known {
+			fields["province"] = append(fields["province"], "Unknown province")
+		}
+	}
+
+	return fields
+}
+
+// clearOtherDefaults unsets the default flags that address now holds on the
+// user's other addresses.
+func clearOtherDefaults(ctx context.Context, address models.Address) error {
+	unset := bson.M{}
+	if address.IsDefaultShipping {
+		unset["isDefaultShipping"] = false
+	}
+	if address.IsDefaultBilling {
+		unset["isDefaultBilling"] = false
+	}
+	if len(unset) == 0 {
+		return nil
+	}
+
+	_, err := database.AddressCollection.UpdateMany(ctx,
+		bson.M{"userId": address.UserID, "_id": bson.M{"$ne": address.ID}},
+		bson.M{"$set": unset},
+	)
+	return err
+}
+
+// findCheckoutAddress loads the user's address by ID, or the address
+// flagged with defaultField when idHex is empty.
+func findCheckoutAddress(ctx context.Context, userID primitive.ObjectID, idHex, defaultField string) (models.Address, error) {
+	filter := bson.M{"userId": userID, defaultField: true}
+	if idHex != "" {
+		id, err := primitive.ObjectIDFromHex(idHex)
+		if err != nil {
+			return models.Address{}, mongo.ErrNoDocuments
+		}
+		filter = bson.M{"userId": userID, "_id": id}
+	}
+
+	var address models.Address
+	err := database.AddressCollection.FindOne(ctx, filter).Decode(&address)
+	if err == mongo.ErrNoDocuments && idHex == "" {
+		return models.Address{}, errAddressRequired
+	}
+	return address, err
+}
+
+func GetAddresses(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := database.AddressCollection.Find(ctx, bson.M{"userId": principal.UserID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var addresses []models.Address = []models.Address{}
+	if err := cursor.All(ctx, &addresses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": addresses})
+}
+
+func CreateAddress(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input addressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	now := time.Now()
+	address := models.Address{
+		ID:        primitive.NewObjectID(),
+		UserID:    principal.UserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	input.apply(&address)
+	if fields := validateAddress(&address); len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := database.AddressCollection.CountDocuments(ctx, bson.M{"userId": principal.UserID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create address"})
+		return
+	}
+	if count >= maxAddressesPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Address book is full"})
+		return
+	}
+
+	// The first address is the default for everything.
+	if count == 0 {
+		address.IsDefaultShipping = true
+		address.IsDefaultBilling = true
+	}
+
+	if _, err := database.AddressCollection.InsertOne(ctx, address); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create address"})
+		return
+	}
+	if err := clearOtherDefaults(ctx, address); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Address created but failed to update defaults"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Address created", "data": address})
+}
+
+func UpdateAddress(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+
+	var input addressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var address models.Address
+	err = database.AddressCollection.FindOne(ctx, bson.M{"_id": objID, "userId": principal.UserID}).Decode(&address)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+		return
+	}
+
+	input.apply(&address)
+	if fields := validateAddress(&address); len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return
+	}
+	address.UpdatedAt = time.Now()
+
+	_, err = database.AddressCollection.ReplaceOne(ctx, bson.M{"_id": objID, "userId": principal.UserID}, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update address"})
+		return
+	}
+	if err := clearOtherDefaults(ctx, address); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Address updated but failed to update defaults"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Address updated", "data": address})
+}
+
+func DeleteAddress(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var deleted models.Address
+	err = database.AddressCollection.FindOneAndDelete(ctx, bson.M{"_id": objID, "userId": principal.UserID}).Decode(&deleted)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete address"})
+		return
+	}
+
+	// Hand the defaults the address held on to the most recent one left.
+	for field, held := range map[string]bool{
+		"isDefaultShipping": deleted.IsDefaultShipping,
+		"isDefaultBilling":  deleted.IsDefaultBilling,
+	} {
+		if !held {
+			continue
+		}
+		if err := promoteDefault(ctx, principal.UserID, field); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Address deleted but failed to update defaults"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Address deleted"})
+}
+
+// promoteDefault flags the user's most recently created address with
+// defaultField. Users without addresses are left alone.
+func promoteDefault(ctx context.Context, userID primitive.ObjectID, defaultField string) error {
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	err := database.AddressCollection.FindOneAndUpdate(ctx,
+		bson.M{"userId": userID},
+		bson.M{"$set": bson.M{defaultField: true}},
+		opts,
+	).Err()
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	return err
+}
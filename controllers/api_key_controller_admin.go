@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/apikey"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/rbac"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreateAPIKey(c *gin.Context) {
+	var body struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	for _, scope := range body.Scopes {
+		isValid := false
+		for _, s := range models.APIKeyScopes {
+			if scope == s {
+				isValid = true
+				break
+			}
+		}
+		if !isValid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope: " + scope, "allowedScopes": models.APIKeyScopes})
+			return
+		}
+
+		// A key may only carry what its creator could do themselves.
+		for _, perm := range rbac.ScopePermissions(scope) {
+			if !rbac.Has(principal.Role, perm) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: scope " + scope + " requires permission " + perm})
+				return
+			}
+		}
+	}
+
+	if body.ExpiresAt != nil && body.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+		return
+	}
+
+	raw, prefix, err := apikey.Generate()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+
+	createdBy := principal.UserID
+
+	key := models.APIKey{
+		ID:        primitive.NewObjectID(),
+		Name:      body.Name,
+		Prefix:    prefix,
+		KeyHash:   apikey.Hash(raw),
+		Scopes:    body.Scopes,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: body.ExpiresAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := database.APIKeyCollection.InsertOne(ctx, key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key created. Store it now, it will not be shown again",
+		"key":     raw,
+		"data":    key,
+	})
+}
+
+func GetAPIKeys(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := database.APIKeyCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var keys []models.APIKey = []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": keys})
+}
+
+func RevokeAPIKey(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := database.APIKeyCollection.UpdateOne(ctx,
+		bson.M{"_id": objID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found or already revoked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
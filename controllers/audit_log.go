@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/middleware"
+	"ecommerce/models"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordAudit appends entry to the audit log, filling in the actor from the
+// authenticated request unless the caller already set one. A failed write
+// is logged but does not fail the request.
+func recordAudit(ctx context.Context, c *gin.Context, entry models.AuditLog) {
+	entry.ID = primitive.NewObjectID()
+	entry.IP = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+	entry.CreatedAt = time.Now()
+
+	if entry.ActorType == "" {
+		p, ok := middleware.GetPrincipal(c)
+		switch {
+		case ok && p.IsAPIKey():
+			entry.ActorType = models.AuditActorAPIKey
+			entry.ActorID = p.APIKeyID
+		case ok:
+			entry.ActorType = models.AuditActorUser
+			entry.ActorID = p.UserID
+		default:
+			entry.ActorType = models.AuditActorAnonymous
+		}
+	}
+
+	if _, err := database.AuditLogCollection.InsertOne(ctx, entry); err != nil {
+		log.Println("⚠️  Failed to write audit log:", entry.Action, err)
+	}
+}
+
+// auditDiff returns the fields that differ between two versions of a
+// document, keyed by their bson name. updatedAt is left out.
+func auditDiff(before, after interface{}) map[string]models.AuditChange {
+	b, err := toBSONMap(before)
+	if err != nil {
+		return nil
+	}
+	a, err := toBSONMap(after)
+	if err != nil {
+		return nil
+	}
+
+	changes := map[string]models.AuditChange{}
+	for key, value := range a {
+		if key == "updatedAt" {
+			continue
+		}
+		if old, ok := b[key]; !ok || !reflect.DeepEqual(old, value) {
+			changes[key] = models.AuditChange{Before: b[key], After: value}
+		}
+	}
+	for key, old := range b {
+		if _, ok := a[key]; !ok && key != "updatedAt" {
+			changes[key] = models.AuditChange{Before: old, After: nil}
+		}
+	}
+	return changes
+}
+
+func toBSONMap(v interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	err = bson.Unmarshal(raw, &m)
+	return m, err
+}
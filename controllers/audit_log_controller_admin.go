@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// auditLogFilter builds the query shared by the list and export endpoints
+// from the action, actorId, targetType, targetId, from and to parameters.
+func auditLogFilter(c *gin.Context) (bson.M, bool) {
+	filter := bson.M{}
+
+	if action := c.Query("action"); action != "" {
+		filter["action"] = action
+	}
+	if targetType := c.Query("targetType"); targetType != "" {
+		filter["targetType"] = targetType
+	}
+	for param, field := range map[string]string{"actorId": "actorId", "targetId": "targetId"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			return nil, false
+		}
+		filter[field] = id
+	}
+
+	createdAt := bson.M{}
+	if from := c.Query("from"); from != "" {
+		t, err := parseDateParam(from, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+			return nil, false
+		}
+		createdAt["$gte"] = t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := parseDateParam(to, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+			return nil, false
+		}
+		createdAt["$lte"] = t
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	return filter, true
+}
+
+func GetAuditLogs(c *gin.Context) {
+	filter, ok := auditLogFilter(c)
+	if !ok {
+		return
+	}
+	page, limit := pagination(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	total, err := database.AuditLogCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := paginate(options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}), page, limit)
+	cursor, err := database.AuditLogCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var logs []models.AuditLog = []models.AuditLog{}
+	if err := cursor.All(ctx, &logs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Fetch success",
+		"data":       logs,
+		"pagination": paginationMeta(page, limit, total),
+	})
+}
+
+// ExportAuditLogs streams every matching entry, oldest first, as
+// newline-delimited JSON.
+func ExportAuditLogs(c *gin.Context) {
+	filter, ok := auditLogFilter(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := database.AuditLogCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit-log-`+time.Now().UTC().Format("20060102T150405Z")+`.ndjson"`)
+	c.Status(http.StatusOK)
+
+	enc := json.NewEncoder(c.Writer)
+	for n := 1; cursor.Next(ctx); n++ {
+		var entry models.AuditLog
+		if err := cursor.Decode(&entry); err != nil {
+			return
+		}
+		if err := enc.Encode(entry); err != nil {
+			return
+		}
+		if n%100 == 0 {
+			c.Writer.Flush()
+		}
+	}
+}
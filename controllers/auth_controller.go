@@ -2,27 +2,28 @@ package controllers
 
 import (
 	"context"
+	"ecommerce/auth"
 	"ecommerce/database"
 	"ecommerce/models"
+	"ecommerce/passwd"
+	"ecommerce/revocation"
+	"log"
 	"net/http"
-	"os"
+	"net/mail"
+	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
-
 func Register(c *gin.Context) {
 	var input struct {
-		Name     string `json:"name" binding:"required"`
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password" binding:"required"`
-		Role     string `json:"role"`
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -30,6 +31,28 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	input.Name = strings.TrimSpace(input.Name)
+	input.Email = strings.TrimSpace(input.Email)
+
+	fields := map[string][]string{}
+	if input.Name == "" {
+		fields["name"] = append(fields["name"], "Name is required")
+	}
+	if input.Email == "" {
+		fields["email"] = append(fields["email"], "Email is required")
+	} else if addr, err := mail.ParseAddress(input.Email); err != nil || addr.Address != input.Email {
+		fields["email"] = append(fields["email"], "Email is not a valid address")
+	}
+	if input.Password == "" {
+		fields["password"] = append(fields["password"], "Password is required")
+	} else if problems := passwordProblems(input.Password, input.Email, input.Name); len(problems) > 0 {
+		fields["password"] = problems
+	}
+	if len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -40,19 +63,18 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	hashed, _ := bcrypt.GenerateFromPassword([]byte(input.Password), 10)
-
-	role := input.Role
-	if role == "" {
-		role = "customer"
+	hashed, err := passwd.Hash(input.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register"})
+		return
 	}
 
 	user := models.User{
 		ID:       primitive.NewObjectID(),
 		Name:     input.Name,
 		Email:    input.Email,
-		Password: string(hashed),
-		Role:     role,
+		Password: hashed,
+		Role:     models.RoleCustomer,
 		CreatedAt: time.Now(),
 	}
 
@@ -62,15 +84,25 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	if err := sendVerificationEmail(ctx, user, user.Email); err != nil {
+		log.Println("⚠️  Failed to send verification email:", err)
+	}
+
+	response := gin.H{
 		"message": "User registered successfully",
 		"user": gin.H{
-			"id":    user.ID.Hex(),
-			"name":  user.Name,
-			"email": user.Email,
-			"role":  user.Role,
+			"id":            user.ID.Hex(),
+			"name":          user.Name,
+			"email":         user.Email,
+			"role":          user.Role,
+			"emailVerified": user.EmailVerified,
 		},
-	})
+	}
+	if adjustments := mergeGuestCart(ctx, c, user.ID); adjustments != nil {
+		response["cart"] = gin.H{"merged": true, "adjustments": adjustments}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 func Login(c *gin.Context) {
@@ -87,35 +119,157 @@ func Login(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	limits := loginLimits(input.Email, c.ClientIP())
+	wait, err := checkLoginThrottle(ctx, limits)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+		return
+	}
+	if wait > 0 {
+		auditLogin(ctx, c, models.AuditLoginFailed, nil, input.Email, gin.H{"reason": "throttled"})
+		respondTooManyAttempts(c, wait)
+		return
+	}
+
+	allowed, emailLocked, err := beginLoginAttempt(ctx, limits)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+		return
+	}
+	if !allowed {
+		auditLogin(ctx, c, models.AuditLoginFailed, nil, input.Email, gin.H{"reason": "throttled"})
+		respondTooManyAttempts(c, loginMaxDelay)
+		return
+	}
+
 	var user models.User
-	err := database.UserCollection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&user)
+	err = database.UserCollection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&user)
 	if err != nil {
+		auditLogin(ctx, c, models.AuditLoginFailed, nil, input.Email, gin.H{"reason": "unknown_email"})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
 	if err != nil {
+		if emailLocked {
+			if err := sendUnlockEmail(ctx, user); err != nil {
+				log.Println("⚠️  Failed to send unlock email:", err)
+			}
+		}
+		auditLogin(ctx, c, models.AuditLoginFailed, &user, input.Email, gin.H{"reason": "bad_password"})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": user.ID.Hex(),
-		"role":   user.Role,
-		"exp":    time.Now().Add(time.Hour * 24).Unix(),
-	})
+	if passwd.NeedsRehash(user.Password) {
+		upgradePasswordHash(ctx, user, input.Password)
+	}
+
+	if user.SuspendedAt != nil {
+		auditLogin(ctx, c, models.AuditLoginFailed, &user, input.Email, gin.H{"reason": "suspended"})
+		rejectSuspended(c, user)
+		return
+	}
 
-	tokenString, _ := token.SignedString(jwtSecret)
+	// The counters are only cleared once the whole login succeeds, so a
+	// known password alone cannot reset them to guess MFA codes. The
+	// attempt counted above carries over to the first code.
+	if user.MFAEnabled {
+		startMFAChallenge(ctx, c, user, true)
+		return
+	}
+
+	_ = clearLoginFailures(ctx, emailAttemptKey(user.Email), ipAttemptKey(c.ClientIP()))
+	if respondWithTokens(ctx, c, user, false) {
+		auditLogin(ctx, c, models.AuditLoginSucceeded, &user, input.Email, gin.H{"mfa": false})
+	}
+}
+
+// auditLogin records a password login attempt. user is nil when no account
+// matches the email.
+func auditLogin(ctx context.Context, c *gin.Context, action string, user *models.User, email string, metadata gin.H) {
+	entry := models.AuditLog{
+		Action:    action,
+		ActorType: models.AuditActorAnonymous,
+		Metadata:  metadata,
+	}
+	entry.Metadata["email"] = email
+	if user != nil {
+		entry.ActorType = models.AuditActorUser
+		entry.ActorID = user.ID
+		entry.TargetType = models.AuditTargetUser
+		entry.TargetID = user.ID
+	}
+	recordAudit(ctx, c, entry)
+}
+
+// upgradePasswordHash rehashes the password at the current bcrypt cost.
+// Failures are only logged; the old hash keeps working.
+func upgradePasswordHash(ctx context.Context, user models.User, password string) {
+	hashed, err := passwd.Hash(password)
+	if err == nil {
+		_, err = database.UserCollection.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "password": user.Password},
+			bson.M{"$set": bson.M{"password": hashed}},
+		)
+	}
+	if err != nil {
+		log.Println("⚠️  Failed to upgrade password hash:", err)
+	}
+}
+
+func RefreshToken(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refreshToken" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	refreshToken, rt, err := rotateRefreshToken(ctx, input.RefreshToken)
+	if err != nil {
+		switch err {
+		case errRefreshTokenReused:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, please login again"})
+		case errRefreshTokenInvalid:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	var user models.User
+	err = database.UserCollection.FindOne(ctx, bson.M{"_id": rt.UserID}).Decode(&user)
+	if err != nil {
+		_ = revokeSession(ctx, rt.FamilyID, rt.UserID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if user.SuspendedAt != nil {
+		_ = revokeSession(ctx, rt.FamilyID, rt.UserID)
+		rejectSuspended(c, user)
+		return
+	}
+
+	touchSession(ctx, c, rt.FamilyID)
+
+	tokenString, err := generateAccessToken(user, rt.FamilyID, rt.MFA)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":    user.ID.Hex(),
-			"name":  user.Name,
-			"email": user.Email,
-			"role":  user.Role,
-			"token": tokenString,
-		},
+		"token":        tokenString,
+		"refreshToken": refreshToken,
+		"expiresIn":    int64(auth.AccessTokenTTL().Seconds()),
 	})
 }
 
@@ -130,25 +284,49 @@ func Logout(c *gin.Context) {
         tokenString = tokenString[7:]
     }
 
-    token, _ := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-        return jwtSecret, nil
-    })
+    claims, err := auth.Parse(tokenString)
 
-    if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-        exp := int64(claims["exp"].(float64))
+    var body struct {
+        RefreshToken string `json:"refreshToken"`
+    }
+    _ = c.ShouldBindJSON(&body)
 
+    if err == nil {
         ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
         defer cancel()
 
-        _, err := database.DB.Collection("blacklist_tokens").InsertOne(ctx, bson.M{
-            "token": tokenString,
-            "exp":   exp,
-        })
-        if err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to blacklist token"})
+        // Ending the session revokes its refresh tokens as well. A refresh
+        // token from the body covers tokens issued before sessions existed.
+        userID, _ := primitive.ObjectIDFromHex(claims.UserID)
+        sessionID, _ := primitive.ObjectIDFromHex(claims.SessionID)
+        if body.RefreshToken != "" {
+            var rt models.RefreshToken
+            err := database.RefreshTokenCollection.FindOne(ctx, bson.M{"tokenHash": hashToken(body.RefreshToken)}).Decode(&rt)
+            if err == nil && rt.UserID == userID {
+                sessionID = rt.FamilyID
+            }
+        }
+        if !sessionID.IsZero() {
+            if err := revokeSession(ctx, sessionID, userID); err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+                return
+            }
+        }
+
+        if err := revocation.Default.RevokeToken(ctx, claims.Id, claims.UserID, claims.ExpiresAtTime()); err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
             return
         }
 
+        recordAudit(ctx, c, models.AuditLog{
+            Action:     models.AuditLogout,
+            ActorType:  models.AuditActorUser,
+            ActorID:    userID,
+            TargetType: models.AuditTargetUser,
+            TargetID:   userID,
+            Metadata:   gin.H{"sessionId": sessionID.Hex()},
+        })
+
         c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
         return
     }
@@ -16,6 +16,7 @@ import (
 func AddToCart(c *gin.Context) {
     var body struct {
         ProductID string `json:"productId"`
+        VariantID string `json:"variantId"`
         Quantity  int    `json:"quantity"`
     }
     if err := c.ShouldBindJSON(&body); err != nil {
@@ -23,32 +24,33 @@ func AddToCart(c *gin.Context) {
         return
     }
 
-    userId, _ := c.Get("userId")
-    objUserID, _ := primitive.ObjectIDFromHex(userId.(string))
+    owner, ok := cartOwner(c, true)
+    if !ok {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start cart"})
+        return
+    }
     objProductID, _ := primitive.ObjectIDFromHex(body.ProductID)
+    objVariantID, ok := parseVariantID(body.VariantID)
+    if !ok {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variantId"})
+        return
+    }
 
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    var product models.Product
-    err := database.ProductCollection.FindOne(ctx, bson.M{"_id": objProductID}).Decode(&product)
+    item, err := findPurchasable(ctx, objProductID, objVariantID)
     if err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+        respondPurchasableError(c, err)
         return
     }
 
-    if body.Quantity > product.Stock {
+    if body.Quantity > item.Stock() {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity exceeds available stock"})
         return
     }
 
-    cartItem := models.CartItem{
-        ID:        primitive.NewObjectID(),
-        UserID:    objUserID,
-        ProductID: objProductID,
-        Quantity:  body.Quantity,
-        CreatedAt: time.Now(),
-    }
+    cartItem := newCartItem(owner, objProductID, objVariantID, body.Quantity)
 
     _, err = database.CartCollection.InsertOne(ctx, cartItem)
     if err != nil {
@@ -56,30 +58,38 @@ func AddToCart(c *gin.Context) {
         return
     }
 
+    // Activity keeps the whole guest cart alive.
+    if cartItem.ExpiresAt != nil {
+        _, _ = database.CartCollection.UpdateMany(ctx, owner, bson.M{"$set": bson.M{"expiresAt": cartItem.ExpiresAt}})
+    }
+
     response := gin.H{
         "cartId":    cartItem.ID,
         "productId": cartItem.ProductID,
         "quantity":  cartItem.Quantity,
         "createdAt": cartItem.CreatedAt,
-        "product": gin.H{
-            "name":  product.Name,
-            "price": product.Price,
-            "stock": product.Stock,
-        },
-        "subtotal": float64(cartItem.Quantity) * product.Price,
+        "product":   item.details(),
+        "subtotal":  float64(cartItem.Quantity) * item.Price(),
     }
 
+    if token := c.Writer.Header().Get(cartTokenHeader); token != "" {
+        c.JSON(http.StatusOK, gin.H{"message": "Added to cart", "data": response, "cartToken": token})
+        return
+    }
     c.JSON(http.StatusOK, gin.H{"message": "Added to cart", "data": response})
 }
 
 func GetCart(c *gin.Context) {
-    userId, _ := c.Get("userId")
-    objUserID, _ := primitive.ObjectIDFromHex(userId.(string))
+    owner, ok := cartOwner(c, false)
+    if !ok {
+        c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": []gin.H{}})
+        return
+    }
 
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    cursor, err := database.CartCollection.Find(ctx, bson.M{"userId": objUserID})
+    cursor, err := database.CartCollection.Find(ctx, owner)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -93,27 +103,54 @@ func GetCart(c *gin.Context) {
 
     var cartWithProducts []gin.H
     for _, item := range cartItems {
-        var product models.Product
-        err := database.ProductCollection.FindOne(ctx, bson.M{"_id": item.ProductID}).Decode(&product)
+        line, err := findPurchasable(ctx, item.ProductID, item.VariantID)
         if err != nil {
+            // Keep lines that can no longer be bought visible so the user
+            // can remove them; checkout rejects them.
+            reason, ok := unavailableReason(err)
+            if !ok {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart"})
+                return
+            }
+            entry := gin.H{
+                "productId":   item.ProductID,
+                "quantity":    item.Quantity,
+                "productName": line.Product.Name,
+                "available":   false,
+                "reason":      reason,
+            }
+            if !item.VariantID.IsZero() {
+                entry["variantId"] = item.VariantID
+            }
+            cartWithProducts = append(cartWithProducts, entry)
             continue
         }
 
-        cartWithProducts = append(cartWithProducts, gin.H{
+        entry := gin.H{
             "productId":   item.ProductID,
             "quantity":    item.Quantity,
-            "productName": product.Name,
-            "price":       product.Price,
-            "total":       float64(item.Quantity) * product.Price,
-        })
+            "productName": line.Product.Name,
+            "price":       line.Price(),
+            "total":       float64(item.Quantity) * line.Price(),
+            "available":   true,
+        }
+        if line.Variant != nil {
+            entry["variantId"] = line.Variant.ID
+            entry["sku"] = line.Variant.SKU
+            entry["options"] = line.Variant.Options
+        }
+        cartWithProducts = append(cartWithProducts, entry)
     }
 
     c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": cartWithProducts})
 }
 
 func UpdateCart(c *gin.Context) {
-	userIDHex := c.MustGet("userId").(string)
-	userID, _ := primitive.ObjectIDFromHex(userIDHex)
+	owner, ok := cartOwner(c, false)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found in cart"})
+		return
+	}
 
 	productId := c.Param("productId")
 	productObjID, err := primitive.ObjectIDFromHex(productId)
@@ -121,6 +158,11 @@ func UpdateCart(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productId"})
 		return
 	}
+	variantObjID, ok := parseVariantID(c.Query("variantId"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variantId"})
+		return
+	}
 
 	var body struct {
 		Quantity int `json:"quantity"`
@@ -134,7 +176,7 @@ func UpdateCart(c *gin.Context) {
 	defer cancel()
 
 	var cartItem models.CartItem
-	err = database.CartCollection.FindOne(ctx, bson.M{"userId": userID, "productId": productObjID}).Decode(&cartItem)
+	err = database.CartCollection.FindOne(ctx, cartItemFilter(owner, productObjID, variantObjID)).Decode(&cartItem)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found in cart"})
@@ -145,14 +187,14 @@ func UpdateCart(c *gin.Context) {
 	}
 
 	// Ambil data product
-	var product models.Product
-	if err := database.ProductCollection.FindOne(ctx, bson.M{"_id": productObjID}).Decode(&product); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	item, err := findPurchasable(ctx, productObjID, variantObjID)
+	if err != nil {
+		respondPurchasableError(c, err)
 		return
 	}
 
 	if body.Quantity == 0 {
-		_, err := database.CartCollection.DeleteOne(ctx, bson.M{"userId": userID, "productId": productObjID})
+		_, err := database.CartCollection.DeleteOne(ctx, cartItemFilter(owner, productObjID, variantObjID))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove product from cart"})
 			return
@@ -161,12 +203,12 @@ func UpdateCart(c *gin.Context) {
 		return
 	}
 
-	if body.Quantity > product.Stock {
+	if body.Quantity > item.Stock() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity exceeds available stock"})
 		return
 	}
 
-	filter := bson.M{"userId": userID, "productId": productObjID}
+	filter := cartItemFilter(owner, productObjID, variantObjID)
 	update := bson.M{"$set": bson.M{"quantity": body.Quantity}}
 
 	_, err = database.CartCollection.UpdateOne(ctx, filter, update)
@@ -178,20 +220,19 @@ func UpdateCart(c *gin.Context) {
 	response := gin.H{
 		"productId": productObjID,
 		"quantity":  body.Quantity,
-		"product": gin.H{
-			"name":  product.Name,
-			"price": product.Price,
-			"stock": product.Stock,
-		},
-		"subtotal": float64(body.Quantity) * product.Price,
+		"product":   item.details(),
+		"subtotal":  float64(body.Quantity) * item.Price(),
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Cart updated", "data": response})
 }
 
 func RemoveFromCart(c *gin.Context) {
-	userIDHex := c.MustGet("userId").(string)
-	userID, _ := primitive.ObjectIDFromHex(userIDHex)
+	owner, ok := cartOwner(c, false)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found in cart"})
+		return
+	}
 
 	productId := c.Param("productId")
 	productObjID, err := primitive.ObjectIDFromHex(productId)
@@ -199,21 +240,23 @@ func RemoveFromCart(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productId"})
 		return
 	}
+	variantObjID, ok := parseVariantID(c.Query("variantId"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variantId"})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	result, err := database.CartCollection.DeleteOne(ctx, bson.M{
-		"userId":    userID,
-		"productId": productObjID,
-	})
+	result, err := database.CartCollection.DeleteOne(ctx, cartItemFilter(owner, productObjID, variantObjID))
 	if err != nil || result.DeletedCount == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found in cart"})
 		return
 	}
 
-	var product models.Product
-	if err := database.ProductCollection.FindOne(ctx, bson.M{"_id": productObjID}).Decode(&product); err != nil {
+	line, err := findPurchasable(ctx, productObjID, variantObjID)
+	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"message":   "Product removed from cart",
 			"productId": productObjID.Hex(),
@@ -221,12 +264,28 @@ func RemoveFromCart(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Product removed from cart",
-		"data": gin.H{
-			"productId": productObjID,
-			"name":      product.Name,
-			"price":     product.Price,
-		},
-	})
+	data := gin.H{
+		"productId": productObjID,
+		"name":      line.Product.Name,
+		"price":     line.Price(),
+	}
+	if line.Variant != nil {
+		data["variantId"] = line.Variant.ID
+		data["sku"] = line.Variant.SKU
+		data["options"] = line.Variant.Options
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Product removed from cart", "data": data})
+}
+
+// cartItemFilter selects the owner's line for a product, or for one
+// variant of it. A zero variantID matches lines without a variant.
+func cartItemFilter(owner bson.M, productID, variantID primitive.ObjectID) bson.M {
+	filter := bson.M{"productId": productID, "variantId": nil}
+	if !variantID.IsZero() {
+		filter["variantId"] = variantID
+	}
+	for k, v := range owner {
+		filter[k] = v
+	}
+	return filter
 }
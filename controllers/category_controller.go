@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// categoryTree nests categories under their parents, ordering siblings by
+// sortOrder and then name.
+func categoryTree(categories []models.Category) []*models.CategoryNode {
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].Name < categories[j].Name
+	})
+
+	nodes := make(map[primitive.ObjectID]*models.CategoryNode, len(categories))
+	for _, cat := range categories {
+		nodes[cat.ID] = &models.CategoryNode{Category: cat, Children: []*models.CategoryNode{}}
+	}
+
+	roots := []*models.CategoryNode{}
+	for _, cat := range categories {
+		node := nodes[cat.ID]
+		if cat.ParentID != nil {
+			if parent, ok := nodes[*cat.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+func GetCategories(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := database.CategoryCollection.Find(ctx, bson.M{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var categories []models.Category
+	if err := cursor.All(ctx, &categories); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": categoryTree(categories)})
+}
+
+// GetCategoryProducts lists the products in a category and all of its
+// subcategories. It takes the same query parameters as GetProductsPublic.
+func GetCategoryProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var category models.Category
+	if err := database.CategoryCollection.FindOne(ctx, bson.M{"slug": c.Param("slug")}).Decode(&category); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	ids := []primitive.ObjectID{category.ID}
+	cursor, err := database.CategoryCollection.Find(ctx, bson.M{"ancestors": category.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var descendants []models.Category
+	if err := cursor.All(ctx, &descendants); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, d := range descendants {
+		ids = append(ids, d.ID)
+	}
+
+	products, meta, ok := listProducts(c, bson.M{"categoryIds": bson.M{"$in": ids}})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Fetch success",
+		"category":   category,
+		"data":       products,
+		"pagination": meta,
+	})
+}
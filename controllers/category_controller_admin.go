@@ -0,0 +1,407 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/search"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+const maxCategoryNameLength = 100
+
+// slugify turns a category name into a URL slug, e.g. "Pakaian & Aksesoris"
+// becomes "pakaian-aksesoris".
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// categoryInput is the body of CreateCategory and UpdateCategory. An empty
+// parentId moves the category to the top level.
+type categoryInput struct {
+	Name      *string `json:"name"`
+	Slug      *string `json:"slug"`
+	ParentID  *string `json:"parentId"`
+	SortOrder *int    `json:"sortOrder"`
+}
+
+// apply copies the input onto category and checks the result. It resolves
+// the parent and returns the field errors, if any.
+func (in categoryInput) apply(ctx context.Context, category *models.Category) (map[string][]string, error) {
+	fields := map[string][]string{}
+
+	if in.Name != nil {
+		category.Name = strings.TrimSpace(*in.Name)
+	}
+	if category.Name == "" {
+		fields["name"] = append(fields["name"], "Name is required")
+	} else if len([]rune(category.Name)) > maxCategoryNameLength {
+		fields["name"] = append(fields["name"], fmt.Sprintf("Name must be at most %d characters", maxCategoryNameLength))
+	}
+
+	if in.Slug != nil {
+		category.Slug = strings.TrimSpace(*in.Slug)
+	}
+	if category.Slug == "" {
+		category.Slug = slugify(category.Name)
+	}
+	if !slugPattern.MatchString(category.Slug) || len(category.Slug) > maxCategoryNameLength {
+		fields["slug"] = append(fields["slug"], "Slug must be lowercase letters and digits separated by single dashes")
+	}
+
+	if in.SortOrder != nil {
+		category.SortOrder = *in.SortOrder
+	}
+
+	if in.ParentID != nil {
+		if *in.ParentID == "" {
+			category.ParentID = nil
+			category.Ancestors = []primitive.ObjectID{}
+		} else if parentID, err := primitive.ObjectIDFromHex(*in.ParentID); err != nil {
+			fields["parentId"] = append(fields["parentId"], "Invalid parent ID")
+		} else {
+			var parent models.Category
+			err := database.CategoryCollection.FindOne(ctx, bson.M{"_id": parentID}).Decode(&parent)
+			switch {
+			case err == mongo.ErrNoDocuments:
+				fields["parentId"] = append(fields["parentId"], "Parent category not found")
+			case err != nil:
+				return nil, err
+			case parent.ID == category.ID || containsObjectID(parent.Ancestors, category.ID):
+				fields["parentId"] = append(fields["parentId"], "A category cannot be moved under itself or its subcategories")
+			default:
+				category.ParentID = &parent.ID
+				category.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+			}
+		}
+	}
+	if category.Ancestors == nil {
+		category.Ancestors = []primitive.ObjectID{}
+	}
+
+	return fields, nil
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func CreateCategory(c *gin.Context) {
+	var body categoryInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	category := models.Category{ID: primitive.NewObjectID(), CreatedAt: now, UpdatedAt: now}
+	fields, err := body.apply(ctx, &category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+	if len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return
+	}
+
+	if _, err := database.CategoryCollection.InsertOne(ctx, category); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Slug already in use"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditCategoryCreated,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   category.ID,
+		Changes:    auditDiff(bson.M{}, category),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category created", "data": category})
+}
+
+func UpdateCategory(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var body categoryInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var previous models.Category
+	if err := database.CategoryCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&previous); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	category := previous
+	category.Ancestors = append([]primitive.ObjectID{}, previous.Ancestors...)
+	fields, err := body.apply(ctx, &category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+	if len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return
+	}
+	category.UpdatedAt = time.Now()
+
+	_, err = database.CategoryCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"name":      category.Name,
+		"slug":      category.Slug,
+		"parentId":  category.ParentID,
+		"ancestors": category.Ancestors,
+		"sortOrder": category.SortOrder,
+		"updatedAt": category.UpdatedAt,
+	}})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug already in use"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+
+	if body.ParentID != nil {
+		if err := moveSubcategories(ctx, objID, category.Ancestors); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move subcategories"})
+			return
+		}
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditCategoryUpdated,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   objID,
+		Changes:    auditDiff(previous, category),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category updated", "data": category})
+}
+
+// moveSubcategories rewrites the ancestors of every category below id
+// after id was moved under newAncestors.
+func moveSubcategories(ctx context.Context, id primitive.ObjectID, newAncestors []primitive.ObjectID) error {
+	cursor, err := database.CategoryCollection.Find(ctx, bson.M{"ancestors": id})
+	if err != nil {
+		return err
+	}
+	var descendants []models.Category
+	if err := cursor.All(ctx, &descendants); err != nil {
+		return err
+	}
+	if len(descendants) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(descendants))
+	for _, d := range descendants {
+		for i, a := range d.Ancestors {
+			if a != id {
+				continue
+			}
+			ancestors := append(append([]primitive.ObjectID{}, newAncestors...), d.Ancestors[i:]...)
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": d.ID}).
+				SetUpdate(bson.M{"$set": bson.M{"ancestors": ancestors}}))
+			break
+		}
+	}
+	_, err = database.CategoryCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// DeleteCategory refuses to delete categories that still have
+// subcategories or products, so nothing is left pointing at it.
+func DeleteCategory(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	children, err := database.CategoryCollection.CountDocuments(ctx, bson.M{"parentId": objID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+	if children > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category still has subcategories"})
+		return
+	}
+
+	products, err := database.ProductCollection.CountDocuments(ctx, bson.M{"categoryIds": objID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+	if products > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Category still has %d products", products)})
+		return
+	}
+
+	var deleted models.Category
+	err = database.CategoryCollection.FindOneAndDelete(ctx, bson.M{"_id": objID}).Decode(&deleted)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditCategoryDeleted,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   objID,
+		Changes:    auditDiff(deleted, bson.M{}),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted", "id": objID.Hex()})
+}
+
+// parseCategoryIDs checks that every ID is valid and names an existing
+// category. It returns the IDs without duplicates.
+func parseCategoryIDs(ctx context.Context, hexIDs []string) ([]primitive.ObjectID, map[string][]string, error) {
+	ids := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, h := range hexIDs {
+		id, err := primitive.ObjectIDFromHex(h)
+		if err != nil {
+			return nil, map[string][]string{"categoryIds": {"Invalid category ID " + h}}, nil
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return ids, nil, nil
+	}
+
+	count, err := database.CategoryCollection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, nil, err
+	}
+	if count != int64(len(ids)) {
+		return nil, map[string][]string{"categoryIds": {"One or more categories do not exist"}}, nil
+	}
+	return ids, nil, nil
+}
+
+// SetProductCategories replaces the categories a product is listed in.
+func SetProductCategories(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var body struct {
+		CategoryIDs []string `json:"categoryIds"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.CategoryIDs == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "categoryIds is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ids, fields, err := parseCategoryIDs(ctx, body.CategoryIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product categories"})
+		return
+	}
+	if len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	var previousProduct models.Product
+	err = database.ProductCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"categoryIds": ids, "updatedAt": now}},
+		opts,
+	).Decode(&previousProduct)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product categories"})
+		return
+	}
+
+	updatedProduct := previousProduct
+	updatedProduct.CategoryIDs = ids
+	updatedProduct.UpdatedAt = now
+
+	if err := search.Default.Index(ctx, updatedProduct); err != nil {
+		log.Println("⚠️  Failed to index product:", err)
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditProductUpdated,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		Changes:    auditDiff(previousProduct, updatedProduct),
+	})
+
+	c.JSON(http.StatusOK, updatedProduct)
+}
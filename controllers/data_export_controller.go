@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/mailer"
+	"ecommerce/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// dataExportInterval limits how often a user may request an export.
+	dataExportInterval = time.Hour
+	// dataExportTimeout is how long generation may take. Exports still
+	// pending after it, e.g. because the server restarted, are reported
+	// as failed.
+	dataExportTimeout = 10 * time.Minute
+	// maxDataExportSize keeps the archive within a Mongo document.
+	maxDataExportSize = 15 << 20
+)
+
+func dataExportLinkTTL() time.Duration {
+	return config.GetEnvDuration("DATA_EXPORT_LINK_TTL", 24*time.Hour)
+}
+
+func dataExportLink(raw string) string {
+	return fmt.Sprintf("%s/api/data-exports/download?token=%s", config.GetEnv("APP_BASE_URL", "http://localhost:8080"), url.QueryEscape(raw))
+}
+
+// withTimedOutStatus reports exports stuck in pending as failed.
+func withTimedOutStatus(export models.DataExport) models.DataExport {
+	if export.Status == models.DataExportPending && time.Since(export.CreatedAt) > dataExportTimeout {
+		export.Status = models.DataExportFailed
+		export.Error = "Export timed out, please request a new one"
+	}
+	return export
+}
+
+// RequestDataExport starts generating an archive of the user's personal
+// data. The download link is returned right away and emailed once the
+// archive is ready; it stops working after DATA_EXPORT_LINK_TTL.
+func RequestDataExport(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": principal.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var last models.DataExport
+	err := database.DataExportCollection.FindOne(ctx,
+		bson.M{"userId": user.ID, "status": bson.M{"$ne": models.DataExportFailed}},
+		options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	).Decode(&last)
+	if err == nil && withTimedOutStatus(last).Status != models.DataExportFailed && time.Since(last.CreatedAt) < dataExportInterval {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "An export was requested recently, please wait before requesting another"})
+		return
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+		return
+	}
+
+	now := time.Now()
+	export := models.DataExport{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Status:    models.DataExportPending,
+		TokenHash: hashToken(raw),
+		CreatedAt: now,
+		ExpiresAt: now.Add(dataExportTimeout + dataExportLinkTTL()),
+	}
+	if _, err := database.DataExportCollection.InsertOne(ctx, export); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+		return
+	}
+
+	go generateDataExport(export.ID, user, raw)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":     "Export started, we will email you when it is ready",
+		"data":        export,
+		"downloadUrl": dataExportLink(raw),
+	})
+}
+
+func generateDataExport(exportID primitive.ObjectID, user models.User, raw string) {
+	ctx, cancel := context.WithTimeout(context.Background(), dataExportTimeout)
+	defer cancel()
+
+	archive, err := buildDataExport(ctx, user)
+	if err == nil && len(archive) > maxDataExportSize {
+		err = fmt.Errorf("archive is %d bytes, over the %d byte limit", len(archive), maxDataExportSize)
+	}
+
+	now := time.Now()
+	if err != nil {
+		log.Println("⚠️  Failed to generate data export:", err)
+		_, _ = database.DataExportCollection.UpdateOne(ctx, bson.M{"_id": exportID}, bson.M{"$set": bson.M{
+			"status":      models.DataExportFailed,
+			"error":       "Failed to generate export, please try again later",
+			"completedAt": now,
+		}})
+		return
+	}
+
+	ttl := dataExportLinkTTL()
+	_, err = database.DataExportCollection.UpdateOne(ctx, bson.M{"_id": exportID}, bson.M{"$set": bson.M{
+		"status":      models.DataExportReady,
+		"archive":     archive,
+		"size":        len(archive),
+		"completedAt": now,
+		"expiresAt":   now.Add(ttl),
+	}})
+	if err != nil {
+		log.Println("⚠️  Failed to store data export:", err)
+		return
+	}
+
+	err = mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your data export is ready",
+		Body:    fmt.Sprintf("Hi %s,\n\nThe copy of your personal data you requested is ready. Download it from the link below:\n\n%s\n\nThe link expires in %s.\n", user.Name, dataExportLink(raw), ttl),
+	})
+	if err != nil {
+		log.Println("⚠️  Failed to send data export email:", err)
+	}
+}
+
+// buildDataExport returns a ZIP archive with one JSON file per kind of data
+// stored about the user.
+func buildDataExport(ctx context.Context, user models.User) ([]byte, error) {
+	var addresses []models.Address = []models.Address{}
+	var cart []models.CartItem = []models.CartItem{}
+	var orders []models.Order = []models.Order{}
+	var auditLogs []models.AuditLog = []models.AuditLog{}
+
+	queries := []struct {
+		coll   *mongo.Collection
+		filter bson.M
+		out    interface{}
+	}{
+		{database.AddressCollection, bson.M{"userId": user.ID}, &addresses},
+		{database.CartCollection, bson.M{"userId": user.ID}, &cart},
+		{database.OrderCollection, bson.M{"userId": user.ID}, &orders},
+		{database.AuditLogCollection, bson.M{"$or": bson.A{bson.M{"actorId": user.ID}, bson.M{"targetId": user.ID}}}, &auditLogs},
+	}
+	for _, q := range queries {
+		cursor, err := q.coll.Find(ctx, q.filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, q.out); err != nil {
+			return nil, err
+		}
+	}
+
+	// Entries where someone else acted on the account, such as staff,
+	// belong in the export but must not reveal who that was.
+	for i := range auditLogs {
+		if auditLogs[i].ActorType != models.AuditActorUser || auditLogs[i].ActorID != user.ID {
+			auditLogs[i].ActorID = primitive.NilObjectID
+			auditLogs[i].IP = ""
+			auditLogs[i].UserAgent = ""
+		}
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"addresses.json", addresses},
+		{"cart.json", cart},
+		{"orders.json", orders},
+		{"audit_log.json", auditLogs},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func GetDataExports(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetProjection(bson.M{"archive": 0})
+	cursor, err := database.DataExportCollection.Find(ctx, bson.M{"userId": principal.UserID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var exports []models.DataExport = []models.DataExport{}
+	if err := cursor.All(ctx, &exports); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range exports {
+		exports[i] = withTimedOutStatus(exports[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": exports})
+}
+
+// DownloadDataExport serves the archive to whoever holds the link, so it
+// works straight from the email without logging in.
+func DownloadDataExport(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var export models.DataExport
+	err := database.DataExportCollection.FindOne(ctx, bson.M{
+		"tokenHash": hashToken(token),
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&export)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired download link"})
+		return
+	}
+
+	switch withTimedOutStatus(export).Status {
+	case models.DataExportPending:
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is still being generated, please try again shortly"})
+		return
+	case models.DataExportFailed:
+		c.JSON(http.StatusGone, gin.H{"error": "Export failed, please request a new one"})
+		return
+	}
+
+	filename := fmt.Sprintf("data-export-%s.zip", export.CreatedAt.UTC().Format("20060102"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", export.Archive)
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/middleware"
+	"ecommerce/models"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// cartTokenHeader carries the anonymous cart token. It is returned when a
+// guest adds their first item and sent back on later cart requests and on
+// login or register to merge the guest cart into the account.
+const cartTokenHeader = "X-Cart-Token"
+
+func guestCartTTL() time.Duration {
+	return config.GetEnvDuration("GUEST_CART_TTL", 30*24*time.Hour)
+}
+
+// cartOwner returns the filter selecting the caller's cart items: the
+// logged in user's, or the guest cart named by the cart token. Without a
+// token a new guest cart is started when create is set; otherwise ok is
+// false.
+func cartOwner(c *gin.Context, create bool) (filter bson.M, ok bool) {
+	if p, ok := middleware.GetPrincipal(c); ok && !p.UserID.IsZero() {
+		return bson.M{"userId": p.UserID}, true
+	}
+
+	token := c.GetHeader(cartTokenHeader)
+	if token == "" {
+		if !create {
+			return nil, false
+		}
+		raw, err := randomToken()
+		if err != nil {
+			return nil, false
+		}
+		token = raw
+		c.Header(cartTokenHeader, token)
+	}
+
+	return bson.M{"guestId": hashToken(token)}, true
+}
+
+// newCartItem builds an item owned by the cart that filter selects.
+func newCartItem(filter bson.M, productID, variantID primitive.ObjectID, quantity int) models.CartItem {
+	item := models.CartItem{
+		ID:        primitive.NewObjectID(),
+		ProductID: productID,
+		VariantID: variantID,
+		Quantity:  quantity,
+		CreatedAt: time.Now(),
+	}
+	if userID, ok := filter["userId"].(primitive.ObjectID); ok {
+		item.UserID = userID
+	} else {
+		item.GuestID, _ = filter["guestId"].(string)
+		expiresAt := item.CreatedAt.Add(guestCartTTL())
+		item.ExpiresAt = &expiresAt
+	}
+	return item
+}
+
+// mergeGuestCart moves the items of the guest cart named by the request's
+// cart token into the user's cart. Quantities of a product in both carts
+// are added up and capped at the current stock; products that no longer
+// exist or are out of stock are dropped. Variants of a product are merged
+// separately. It returns the adjustments made,
+// or nil when there was nothing to merge.
+func mergeGuestCart(ctx context.Context, c *gin.Context, userID primitive.ObjectID) []gin.H {
+	token := c.GetHeader(cartTokenHeader)
+	if token == "" {
+		return nil
+	}
+	guestFilter := bson.M{"guestId": hashToken(token)}
+
+	cursor, err := database.CartCollection.Find(ctx, guestFilter)
+	if err != nil {
+		log.Println("⚠️  Failed to load guest cart:", err)
+		return nil
+	}
+	var guestItems []models.CartItem
+	if err := cursor.All(ctx, &guestItems); err != nil || len(guestItems) == 0 {
+		return nil
+	}
+
+	cursor, err = database.CartCollection.Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		log.Println("⚠️  Failed to load cart for merge:", err)
+		return nil
+	}
+	var userItems []models.CartItem
+	if err := cursor.All(ctx, &userItems); err != nil {
+		log.Println("⚠️  Failed to load cart for merge:", err)
+		return nil
+	}
+
+	type line struct{ productID, variantID primitive.ObjectID }
+	quantities := map[line]int{}
+	for _, item := range userItems {
+		quantities[line{item.ProductID, item.VariantID}] += item.Quantity
+	}
+	guestQuantities := map[line]int{}
+	var order []line
+	for _, item := range guestItems {
+		key := line{item.ProductID, item.VariantID}
+		if _, seen := guestQuantities[key]; !seen {
+			order = append(order, key)
+		}
+		guestQuantities[key] += item.Quantity
+	}
+
+	userFilter := bson.M{"userId": userID}
+	adjustments := []gin.H{}
+	for _, key := range order {
+		requested := quantities[key] + guestQuantities[key]
+		adjustment := gin.H{"productId": key.productID, "requested": requested}
+		if !key.variantID.IsZero() {
+			adjustment["variantId"] = key.variantID
+		}
+
+		item, err := findPurchasable(ctx, key.productID, key.variantID)
+		if err != nil || item.Stock() <= 0 {
+			adjustment["quantity"], adjustment["reason"] = 0, "unavailable"
+			adjustments = append(adjustments, adjustment)
+			continue
+		}
+
+		quantity := requested
+		if quantity > item.Stock() {
+			quantity = item.Stock()
+			adjustment["quantity"], adjustment["reason"] = quantity, "limited_by_stock"
+			adjustments = append(adjustments, adjustment)
+		}
+
+		// Replace the user's items for the line with one merged item.
+		if _, err := database.CartCollection.DeleteMany(ctx, cartItemFilter(userFilter, key.productID, key.variantID)); err != nil {
+			log.Println("⚠️  Failed to merge guest cart:", err)
+			return nil
+		}
+		if _, err := database.CartCollection.InsertOne(ctx, newCartItem(userFilter, key.productID, key.variantID, quantity)); err != nil {
+			log.Println("⚠️  Failed to merge guest cart:", err)
+			return nil
+		}
+	}
+
+	if _, err := database.CartCollection.DeleteMany(ctx, guestFilter); err != nil {
+		log.Println("⚠️  Failed to clear guest cart:", err)
+	}
+
+	return adjustments
+}
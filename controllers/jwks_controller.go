@@ -0,0 +1,13 @@
+package controllers
+
+import (
+	"ecommerce/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.Keys.JWKS())
+}
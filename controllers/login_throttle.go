@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/mailer"
+	"ecommerce/models"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Failed logins are tracked per email and per client IP in Mongo so limits
+// hold across restarts and replicas. After a few failures every further
+// attempt has to wait an exponentially growing delay, and reaching the
+// limit locks the key for LOGIN_LOCKOUT_DURATION.
+const (
+	loginDelayAfter = 3
+	loginMaxDelay   = 30 * time.Second
+)
+
+type loginLimit struct {
+	key         string
+	maxFailures int
+}
+
+func loginLimits(email, ip string) []loginLimit {
+	return []loginLimit{
+		{key: emailAttemptKey(email), maxFailures: config.GetEnvInt("LOGIN_MAX_FAILURES_EMAIL", 5)},
+		{key: ipAttemptKey(ip), maxFailures: config.GetEnvInt("LOGIN_MAX_FAILURES_IP", 20)},
+	}
+}
+
+func emailAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func loginDelay(failures int) time.Duration {
+	if failures < loginDelayAfter {
+		return 0
+	}
+	delay := time.Second * time.Duration(math.Pow(2, float64(failures-loginDelayAfter)))
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+// checkLoginThrottle returns how long the caller must wait before another
+// attempt is allowed, or zero if it may proceed.
+func checkLoginThrottle(ctx context.Context, limits []loginLimit) (time.Duration, error) {
+	keys := make([]string, 0, len(limits))
+	for _, l := range limits {
+		keys = append(keys, l.key)
+	}
+
+	cursor, err := database.LoginAttemptCollection.Find(ctx, bson.M{"key": bson.M{"$in": keys}})
+	if err != nil {
+		return 0, err
+	}
+
+	var attempts []models.LoginAttempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	for _, a := range attempts {
+		if a.LockedUntil != nil {
+			if now.Before(*a.LockedUntil) {
+				if d := a.LockedUntil.Sub(now); d > wait {
+					wait = d
+				}
+				continue
+			}
+
+			// The lock has expired, start counting from scratch.
+			_, _ = database.LoginAttemptCollection.DeleteOne(ctx, bson.M{"_id": a.ID})
+			continue
+		}
+
+		if d := a.LastFailureAt.Add(loginDelay(a.Failures)).Sub(now); d > wait {
+			wait = d
+		}
+	}
+
+	return wait, nil
+}
+
+// beginLoginAttempt counts an attempt against every limit before the
+// credentials are checked; a successful login clears the counters again.
+// Each counter is raised with a single guarded $inc, so concurrent attempts
+// cannot get past the limit: once a key is at its limit the update finds no
+// document, the upsert collides with the unique key index and ok is false.
+// emailLocked reports whether this attempt locked the email key.
+func beginLoginAttempt(ctx context.Context, limits []loginLimit) (ok, emailLocked bool, err error) {
+	now := time.Now()
+	lockout := config.GetEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+
+	for i, l := range limits {
+		var attempt models.LoginAttempt
+		err := database.LoginAttemptCollection.FindOneAndUpdate(ctx,
+			bson.M{"key": l.key, "failures": bson.M{"$lt": l.maxFailures}},
+			bson.M{
+				"$inc": bson.M{"failures": 1},
+				"$set": bson.M{"lastFailureAt": now, "updatedAt": now},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&attempt)
+		if mongo.IsDuplicateKeyError(err) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+
+		if attempt.Failures >= l.maxFailures {
+			result, err := database.LoginAttemptCollection.UpdateOne(ctx,
+				bson.M{"_id": attempt.ID, "lockedUntil": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"lockedUntil": now.Add(lockout)}},
+			)
+			if err == nil && result.ModifiedCount == 1 && i == 0 {
+				emailLocked = true
+			}
+		}
+	}
+
+	return true, emailLocked, nil
+}
+
+// clearLoginFailures resets the counters of the given keys.
+func clearLoginFailures(ctx context.Context, keys ...string) error {
+	_, err := database.LoginAttemptCollection.DeleteMany(ctx, bson.M{"key": bson.M{"$in": keys}})
+	return err
+}
+
+func respondTooManyAttempts(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":      "Too many failed login attempts, please try again later",
+		"retryAfter": seconds,
+	})
+}
+
+func sendUnlockEmail(ctx context.Context, user models.User) error {
+	raw, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	unlock := models.AccountUnlock{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(24 * time.Hour),
+		CreatedAt: now,
+	}
+	if _, err := database.AccountUnlockCollection.InsertOne(ctx, unlock); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/unlock-account?token=%s", config.GetEnv("APP_BASE_URL", "http://localhost:8080"), url.QueryEscape(raw))
+
+	return mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been locked",
+		Body:    fmt.Sprintf("Hi %s,\n\nWe locked your account after several failed login attempts. If this was you, open the link below to unlock it right away:\n\n%s\n\nIf this was not you, consider resetting your password.\n", user.Name, link),
+	})
+}
+
+func UnlockAccount(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var unlock models.AccountUnlock
+	err := database.AccountUnlockCollection.FindOneAndDelete(ctx, bson.M{"tokenHash": hashToken(token)}).Decode(&unlock)
+	if err != nil || time.Now().After(unlock.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired unlock token"})
+		return
+	}
+
+	if err := clearLoginFailures(ctx, emailAttemptKey(unlock.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
+}
@@ -0,0 +1,412 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/rbac"
+	"ecommerce/totp"
+	"encoding/base32"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+	mfaRecoveryCodeCount    = 10
+)
+
+func requireAdminMFA() bool {
+	return config.GetEnvBool("REQUIRE_ADMIN_MFA", false)
+}
+
+// startMFAChallenge answers the first step of login for a user with MFA
+// enabled. No tokens are issued until LoginMFA succeeds. throttleCounted
+// tells whether that step already counted an attempt against the login
+// throttle.
+func startMFAChallenge(ctx context.Context, c *gin.Context, user models.User, throttleCounted bool) {
+	raw, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA challenge"})
+		return
+	}
+
+	now := time.Now()
+	challenge := models.MFAChallenge{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(mfaChallengeTTL),
+		CreatedAt: now,
+
+		ThrottleCounted: throttleCounted,
+	}
+	if _, err := database.MFAChallengeCollection.InsertOne(ctx, challenge); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mfaRequired": true,
+		"mfaToken":    raw,
+		"expiresIn":   int64(mfaChallengeTTL.Seconds()),
+	})
+}
+
+func LoginMFA(c *gin.Context) {
+	var input struct {
+		MFAToken     string `json:"mfaToken" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recoveryCode"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil || (input.Code == "" && input.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var challenge models.MFAChallenge
+	err := database.MFAChallengeCollection.FindOneAndUpdate(ctx,
+		bson.M{
+			"tokenHash": hashToken(input.MFAToken),
+			"expiresAt": bson.M{"$gt": time.Now()},
+			"attempts":  bson.M{"$lt": mfaChallengeMaxAttempts},
+		},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+	).Decode(&challenge)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA challenge, please login again"})
+		return
+	}
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": challenge.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA challenge, please login again"})
+		return
+	}
+
+	// Codes count against the same per-account limits as passwords, so
+	// starting new challenges does not buy more guesses. The first code of
+	// a password login rides on the attempt the password step counted;
+	// challenge.Attempts is the count before this request.
+	var emailLocked bool
+	if !challenge.ThrottleCounted || challenge.Attempts > 0 {
+		limits := loginLimits(user.Email, c.ClientIP())
+		wait, err := checkLoginThrottle(ctx, limits)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify MFA code"})
+			return
+		}
+		if wait > 0 {
+			respondTooManyAttempts(c, wait)
+			return
+		}
+		var allowed bool
+		allowed, emailLocked, err = beginLoginAttempt(ctx, limits)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify MFA code"})
+			return
+		}
+		if !allowed {
+			respondTooManyAttempts(c, loginMaxDelay)
+			return
+		}
+	}
+
+	if !verifySecondFactor(ctx, user, input.Code, input.RecoveryCode) {
+		if emailLocked {
+			if err := sendUnlockEmail(ctx, user); err != nil {
+				log.Println("⚠️  Failed to send unlock email:", err)
+			}
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid MFA code"})
+		return
+	}
+
+	_, _ = database.MFAChallengeCollection.DeleteOne(ctx, bson.M{"_id": challenge.ID})
+	_ = clearLoginFailures(ctx, emailAttemptKey(user.Email), ipAttemptKey(c.ClientIP()))
+
+	if respondWithTokens(ctx, c, user, true) {
+		auditLogin(ctx, c, models.AuditLoginSucceeded, &user, user.Email, gin.H{"mfa": true})
+	}
+}
+
+func EnrollMFA(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "MFA is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate MFA secret"})
+		return
+	}
+
+	_, err = database.UserCollection.UpdateOne(ctx, bson.M{"_id": objUserID}, bson.M{"$set": bson.M{"mfaPendingSecret": secret}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scan the QR code with your authenticator app, then confirm with a code",
+		"data": gin.H{
+			"secret":          secret,
+			"provisioningUri": totp.ProvisioningURI(config.GetEnv("MFA_ISSUER", "Ecommerce"), user.Email, secret),
+		},
+	})
+}
+
+func ConfirmMFA(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.MFAPendingSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No MFA enrollment in progress"})
+		return
+	}
+
+	step, ok := totp.Validate(user.MFAPendingSecret, input.Code, time.Now(), 1)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid MFA code"})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	_, err = database.UserCollection.UpdateOne(ctx,
+		bson.M{"_id": objUserID, "mfaPendingSecret": user.MFAPendingSecret},
+		bson.M{
+			"$set": bson.M{
+				"mfaEnabled":       true,
+				"mfaSecret":        user.MFAPendingSecret,
+				"mfaLastStep":      step,
+				"mfaRecoveryCodes": hashes,
+			},
+			"$unset": bson.M{"mfaPendingSecret": ""},
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable MFA"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "MFA enabled. Store the recovery codes somewhere safe and login again to start an MFA session",
+		"data":    gin.H{"recoveryCodes": codes},
+	})
+}
+
+func DisableMFA(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	var input struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.MFAEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "MFA is not enabled"})
+		return
+	}
+	if rbac.IsStaff(user.Role) && requireAdminMFA() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "MFA is mandatory for staff accounts"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+	if !verifySecondFactor(ctx, user, input.Code, "") {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid MFA code"})
+		return
+	}
+
+	_, err := database.UserCollection.UpdateOne(ctx, bson.M{"_id": objUserID}, bson.M{
+		"$set":   bson.M{"mfaEnabled": false},
+		"$unset": bson.M{"mfaSecret": "", "mfaLastStep": "", "mfaRecoveryCodes": "", "mfaPendingSecret": ""},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable MFA"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA disabled"})
+}
+
+func RegenerateRecoveryCodes(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.MFAEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "MFA is not enabled"})
+		return
+	}
+	if !verifySecondFactor(ctx, user, input.Code, "") {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid MFA code"})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	_, err = database.UserCollection.UpdateOne(ctx, bson.M{"_id": objUserID}, bson.M{"$set": bson.M{"mfaRecoveryCodes": hashes}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recovery codes regenerated", "data": gin.H{"recoveryCodes": codes}})
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+// Both are consumed atomically so the same value cannot be used twice.
+func verifySecondFactor(ctx context.Context, user models.User, code, recoveryCode string) bool {
+	if !user.MFAEnabled || user.MFASecret == "" {
+		return false
+	}
+
+	if code != "" {
+		step, ok := acceptTOTP(user, code, time.Now())
+		if !ok {
+			return false
+		}
+
+		result, err := database.UserCollection.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "mfaLastStep": bson.M{"$lt": step}},
+			bson.M{"$set": bson.M{"mfaLastStep": step}},
+		)
+		return err == nil && result.ModifiedCount == 1
+	}
+
+	hash := hashToken(normalizeRecoveryCode(recoveryCode))
+	result, err := database.UserCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "mfaRecoveryCodes": hash},
+		bson.M{"$pull": bson.M{"mfaRecoveryCodes": hash}},
+	)
+	return err == nil && result.ModifiedCount == 1
+}
+
+// acceptTOTP validates code at t, allowing one step of clock drift, and
+// rejects codes from steps the user already logged in with so a code works
+// only once.
+func acceptTOTP(user models.User, code string, t time.Time) (int64, bool) {
+	step, ok := totp.Validate(user.MFASecret, code, t, 1)
+	if !ok || step <= user.MFALastStep {
+		return 0, false
+	}
+	return step, true
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	hashes := make([]string, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"ecommerce/models"
+	"ecommerce/totp"
+	"testing"
+	"time"
+)
+
+func TestAcceptTOTPRejectsReplay(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Unix(1111111111, 0)
+	step := totp.Step(now)
+	code, err := totp.CodeAt(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		lastStep int64
+		ok       bool
+	}{
+		{"fresh code", 0, true},
+		{"older code used before", step - 1, true},
+		{"same code used before", step, false},
+		{"newer code used before", step + 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.User{MFAEnabled: true, MFASecret: secret, MFALastStep: tt.lastStep}
+			got, ok := acceptTOTP(user, code, now)
+			if ok != tt.ok {
+				t.Fatalf("acceptTOTP ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got != step {
+				t.Errorf("acceptTOTP step = %d, want %d", got, step)
+			}
+		})
+	}
+}
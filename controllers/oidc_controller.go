@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/oidc"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+var errOIDCEmailUnverified = errors.New("email not verified by provider")
+
+func GetOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": oidc.Names()})
+}
+
+func OIDCLogin(c *gin.Context) {
+	provider, err := oidc.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Println("⚠️  OIDC discovery failed:", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Login provider is unavailable"})
+		return
+	}
+
+	now := time.Now()
+	_, err = database.OIDCStateCollection.InsertOne(ctx, models.OIDCState{
+		ID:           primitive.NewObjectID(),
+		Provider:     provider.Name,
+		StateHash:    hashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(oidcStateTTL),
+		CreatedAt:    now,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "application/json") {
+		c.JSON(http.StatusOK, gin.H{"authorizationUrl": authURL})
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func OIDCCallback(c *gin.Context) {
+	provider, err := oidc.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login was not completed: " + errCode})
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code or state"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var saved models.OIDCState
+	err = database.OIDCStateCollection.FindOneAndDelete(ctx, bson.M{
+		"stateHash": hashToken(state),
+		"provider":  provider.Name,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&saved)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+		return
+	}
+
+	claims, err := provider.Exchange(ctx, code, saved.CodeVerifier, saved.Nonce)
+	if err != nil {
+		log.Println("⚠️  OIDC code exchange failed:", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to verify login with provider"})
+		return
+	}
+
+	user, err := findOrLinkOIDCUser(ctx, provider.Name, claims)
+	if err != nil {
+		switch err {
+		case errOIDCEmailUnverified:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your email address is not verified by the login provider"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+		}
+		return
+	}
+
+	if rejectSuspended(c, user) {
+		return
+	}
+
+	if user.MFAEnabled {
+		startMFAChallenge(ctx, c, user, false)
+		return
+	}
+
+	respondWithTokens(ctx, c, user, false)
+}
+
+// findOrLinkOIDCUser resolves the external identity to a local user. Known
+// identities log straight in; otherwise an existing user with the same
+// provider-verified email gets the identity linked, or a new customer is
+// created.
+func findOrLinkOIDCUser(ctx context.Context, provider string, claims oidc.Claims) (models.User, error) {
+	var user models.User
+	err := database.UserCollection.FindOne(ctx, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": claims.Subject}},
+	}).Decode(&user)
+	if err == nil {
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return models.User{}, err
+	}
+
+	// Linking by email is only safe when the provider vouches for it.
+	if claims.Email == "" || !claims.EmailVerified {
+		return models.User{}, errOIDCEmailUnverified
+	}
+
+	now := time.Now()
+	identity := models.Identity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		LinkedAt: now,
+	}
+
+	err = database.UserCollection.FindOne(ctx, bson.M{"email": claims.Email}).Decode(&user)
+	if err == nil {
+		set := bson.M{}
+		if !user.EmailVerified {
+			set["emailVerified"] = true
+			set["emailVerifiedAt"] = now
+		}
+		update := bson.M{"$push": bson.M{"identities": identity}}
+		if len(set) > 0 {
+			update["$set"] = set
+		}
+
+		if _, err := database.UserCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+			return models.User{}, err
+		}
+		user.Identities = append(user.Identities, identity)
+		user.EmailVerified = true
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return models.User{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = strings.Split(claims.Email, "@")[0]
+	}
+
+	// Users created from an external identity have no password and can only
+	// sign in through the provider until they set one via forgot-password.
+	user = models.User{
+		ID:              primitive.NewObjectID(),
+		Name:            name,
+		Email:           claims.Email,
+		Role:            models.RoleCustomer,
+		CreatedAt:       now,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+		Identities:      []models.Identity{identity},
+	}
+	if _, err := database.UserCollection.InsertOne(ctx, user); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
@@ -11,33 +11,87 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func Checkout(c *gin.Context) {
-	userId, _ := c.Get("userId")
-	objUserID, _ := primitive.ObjectIDFromHex(userId.(string))
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
 
 	var body struct {
 		ProductIDs []string `json:"productIds"`
+
+		// AddressID and BillingAddressID default to the user's default
+		// shipping and billing addresses.
+		AddressID        string `json:"addressId"`
+		BillingAddressID string `json:"billingAddressId"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil || len(body.ProductIDs) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productIds"})
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if requireVerifiedEmail() {
+		var user models.User
+		err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		if !user.EmailVerified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email before checking out"})
+			return
+		}
+	}
+
+	shipping, err := findCheckoutAddress(ctx, objUserID, body.AddressID, "isDefaultShipping")
+	if err != nil {
+		switch err {
+		case errAddressRequired:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Please add a shipping address before checking out"})
+		case mongo.ErrNoDocuments:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Shipping address not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch address"})
+		}
+		return
+	}
+	billing, err := findCheckoutAddress(ctx, objUserID, body.BillingAddressID, "isDefaultBilling")
+	if err == errAddressRequired {
+		billing, err = shipping, nil
+	}
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Billing address not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch address"})
+		}
+		return
+	}
+	shippingAddress, billingAddress := shipping.Snapshot(), billing.Snapshot()
+
 	var objIDs []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
 	for _, pid := range body.ProductIDs {
 		oid, err := primitive.ObjectIDFromHex(pid)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productId format"})
 			return
 		}
-		objIDs = append(objIDs, oid)
+		if !seen[oid] {
+			seen[oid] = true
+			objIDs = append(objIDs, oid)
+		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+	// Every cart line of the selected products is checked out, including
+	// each variant of a product.
 	cursor, err := database.CartCollection.Find(ctx, bson.M{
 		"userId":    objUserID,
 		"productId": bson.M{"$in": objIDs},
@@ -53,77 +107,64 @@ func Checkout(c *gin.Context) {
 		return
 	}
 
-	if len(cartItems) != len(objIDs) {
+	inCart := map[primitive.ObjectID]bool{}
+	for _, item := range cartItems {
+		inCart[item.ProductID] = true
+	}
+	if len(inCart) != len(objIDs) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "One or more products are not in your cart",
 		})
 		return
 	}
 
-	type ProductDetail struct {
-		ID       primitive.ObjectID `json:"id"`
-		Name     string             `json:"name"`
-		Price    float64            `json:"price"`
-		Quantity int                `json:"quantity"`
-	}
-
 	var orderItems []models.OrderItem
-	var productDetails []ProductDetail
+	var productDetails []orderProductDetail
 	var total float64
-	var updatedProducts []struct {
-		ProductID primitive.ObjectID
-		Quantity  int
-	}
+	var taken []takenStock
 
-	for _, item := range cartItems {
-		var product models.Product
-		err := database.ProductCollection.FindOne(ctx, bson.M{"_id": item.ProductID}).Decode(&product)
+	lines := make([]purchasable, len(cartItems))
+	for i, item := range cartItems {
+		line, err := findPurchasable(ctx, item.ProductID, item.VariantID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Product not found"})
+			respondPurchasableError(c, err)
 			return
 		}
-		if item.Quantity > product.Stock {
+		if item.Quantity > line.Stock() {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("Not enough stock for %s, available: %d", product.Name, product.Stock),
+				"error": fmt.Sprintf("Not enough stock for %s, available: %d", lineName(line), line.Stock()),
 			})
 			return
 		}
+		lines[i] = line
 	}
 
-	for _, item := range cartItems {
-		var product models.Product
-		_ = database.ProductCollection.FindOne(ctx, bson.M{"_id": item.ProductID}).Decode(&product)
-
-		_, err = database.ProductCollection.UpdateOne(
-			ctx,
-			bson.M{"_id": product.ID, "stock": bson.M{"$gte": item.Quantity}},
-			bson.M{"$inc": bson.M{"stock": -item.Quantity}},
-		)
+	var cartItemIDs []primitive.ObjectID
+	for i, item := range cartItems {
+		line := lines[i]
+
+		ok, err := takeStock(ctx, line.Product.ID, line.VariantID(), item.Quantity)
 		if err != nil {
-			rollbackStock(ctx, updatedProducts)
+			rollbackStock(ctx, taken)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
 			return
 		}
+		if !ok {
+			rollbackStock(ctx, taken)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Not enough stock for %s", lineName(line)),
+			})
+			return
+		}
 
-		updatedProducts = append(updatedProducts, struct {
-			ProductID primitive.ObjectID
-			Quantity  int
-		}{ProductID: product.ID, Quantity: item.Quantity})
-
-		orderItems = append(orderItems, models.OrderItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     product.Price,
-		})
+		taken = append(taken, takenStock{ProductID: line.Product.ID, VariantID: line.VariantID(), Quantity: item.Quantity})
+		cartItemIDs = append(cartItemIDs, item.ID)
 
-		productDetails = append(productDetails, ProductDetail{
-			ID:       product.ID,
-			Name:     product.Name,
-			Price:    product.Price,
-			Quantity: item.Quantity,
-		})
+		orderItem := line.orderItem(item.Quantity)
+		orderItems = append(orderItems, orderItem)
+		productDetails = append(productDetails, newOrderProductDetail(line.Product.Name, orderItem))
 
-		total += product.Price * float64(item.Quantity)
+		total += line.Price() * float64(item.Quantity)
 	}
 
 	order := models.Order{
@@ -133,19 +174,19 @@ func Checkout(c *gin.Context) {
 		Total:     total,
 		Status:    "pending",
 		CreatedAt: time.Now().Unix(),
+
+		ShippingAddress: &shippingAddress,
+		BillingAddress:  &billingAddress,
 	}
 
 	_, err = database.OrderCollection.InsertOne(ctx, order)
 	if err != nil {
-		rollbackStock(ctx, updatedProducts)
+		rollbackStock(ctx, taken)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
 		return
 	}
 
-	_, _ = database.CartCollection.DeleteMany(ctx, bson.M{
-		"userId":    objUserID,
-		"productId": bson.M{"$in": objIDs},
-	})
+	_, _ = database.CartCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": cartItemIDs}})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Checkout success",
@@ -156,13 +197,19 @@ func Checkout(c *gin.Context) {
 			"status":    order.Status,
 			"products":  productDetails,
 			"createdAt": order.CreatedAt,
+
+			"shippingAddress": order.ShippingAddress,
+			"billingAddress":  order.BillingAddress,
 		},
 	})
 }
 
 func GetOrders(c *gin.Context) {
-	userId, _ := c.Get("userId")
-	objUserID, _ := primitive.ObjectIDFromHex(userId.(string))
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -179,16 +226,9 @@ func GetOrders(c *gin.Context) {
 		return
 	}
 
-	type ProductDetail struct {
-		ID       primitive.ObjectID `json:"id"`
-		Name     string             `json:"name"`
-		Price    float64            `json:"price"`
-		Quantity int                `json:"quantity"`
-	}
-
 	var resp []gin.H
 	for _, order := range orders {
-		var products []ProductDetail
+		var products []orderProductDetail
 		for _, item := range order.Products {
 			var product models.Product
 			err := database.ProductCollection.FindOne(ctx, bson.M{"_id": item.ProductID}).Decode(&product)
@@ -196,12 +236,7 @@ func GetOrders(c *gin.Context) {
 				continue
 			}
 
-			products = append(products, ProductDetail{
-				ID:       product.ID,
-				Name:     product.Name,
-				Price:    product.Price,
-				Quantity: item.Quantity,
-			})
+			products = append(products, newOrderProductDetail(product.Name, item))
 		}
 
 		resp = append(resp, gin.H{
@@ -210,6 +245,9 @@ func GetOrders(c *gin.Context) {
 			"status":    order.Status,
 			"products":  products,
 			"createdAt": order.CreatedAt,
+
+			"shippingAddress": order.ShippingAddress,
+			"billingAddress":  order.BillingAddress,
 		})
 	}
 
@@ -217,12 +255,11 @@ func GetOrders(c *gin.Context) {
 }
 
 func CancelOrder(c *gin.Context) {
-	userId, _ := c.Get("userId")
-	objUserID, err := primitive.ObjectIDFromHex(userId.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId"})
+	principal, ok := currentUser(c)
+	if !ok {
 		return
 	}
+	objUserID := principal.UserID
 
 	orderId := c.Param("id")
 	orderObjID, err := primitive.ObjectIDFromHex(orderId)
@@ -256,15 +293,51 @@ func CancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Order canceled"})
 }
 
-func rollbackStock(ctx context.Context, updated []struct {
+// orderProductDetail is an order line as shown to the customer.
+type orderProductDetail struct {
+	ID        primitive.ObjectID `json:"id"`
+	Name      string             `json:"name"`
+	Price     float64            `json:"price"`
+	Quantity  int                `json:"quantity"`
+	VariantID string             `json:"variantId,omitempty"`
+	SKU       string             `json:"sku,omitempty"`
+	Options   map[string]string  `json:"options,omitempty"`
+}
+
+func newOrderProductDetail(name string, item models.OrderItem) orderProductDetail {
+	detail := orderProductDetail{
+		ID:       item.ProductID,
+		Name:     name,
+		Price:    item.Price,
+		Quantity: item.Quantity,
+		SKU:      item.SKU,
+		Options:  item.Options,
+	}
+	if !item.VariantID.IsZero() {
+		detail.VariantID = item.VariantID.Hex()
+	}
+	return detail
+}
+
+// lineName names a cart line in error messages, adding the SKU for
+// variants.
+func lineName(line purchasable) string {
+	if line.Variant != nil {
+		return fmt.Sprintf("%s (%s)", line.Product.Name, line.Variant.SKU)
+	}
+	return line.Product.Name
+}
+
+// takenStock records stock taken during checkout so it can be returned if
+// the checkout fails part way.
+type takenStock struct {
 	ProductID primitive.ObjectID
+	VariantID primitive.ObjectID
 	Quantity  int
-}) {
-	for _, u := range updated {
-		_, _ = database.ProductCollection.UpdateOne(
-			ctx,
-			bson.M{"_id": u.ProductID},
-			bson.M{"$inc": bson.M{"stock": u.Quantity}},
-		)
+}
+
+func rollbackStock(ctx context.Context, taken []takenStock) {
+	for _, t := range taken {
+		_ = returnStock(ctx, t.ProductID, t.VariantID, t.Quantity)
 	}
-}
\ No newline at end of file
+}
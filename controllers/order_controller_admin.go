@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -124,13 +125,26 @@ func UpdateOrderStatus(c *gin.Context) {
 	}
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
+	// Only apply the transition if nobody changed the status since it was
+	// checked above.
 	var updatedOrder models.Order
-	err = database.OrderCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, update, opts).Decode(&updatedOrder)
+	err = database.OrderCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID, "status": currentStatus}, update, opts).Decode(&updatedOrder)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order status changed meanwhile, please reload and try again"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
 		return
 	}
 
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditOrderStatusSet,
+		TargetType: models.AuditTargetOrder,
+		TargetID:   objID,
+		Changes:    map[string]models.AuditChange{"status": {Before: currentStatus, After: updatedOrder.Status}},
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Order status updated",
 		"data":    updatedOrder,
@@ -151,15 +165,23 @@ func CancelOrderAdmin(c *gin.Context) {
 	filter := bson.M{"_id": objID, "status": bson.M{"$in": []string{"pending", "paid"}}}
 	update := bson.M{"$set": bson.M{"status": "canceled", "updatedAt": time.Now()}}
 
-	result, err := database.OrderCollection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
+	var previousOrder models.Order
+	err = database.OrderCollection.FindOneAndUpdate(ctx, filter, update).Decode(&previousOrder)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Order cannot be canceled"})
 		return
 	}
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Order cannot be canceled"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
 		return
 	}
 
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditOrderCanceled,
+		TargetType: models.AuditTargetOrder,
+		TargetID:   objID,
+		Changes:    map[string]models.AuditChange{"status": {Before: previousOrder.Status, After: "canceled"}},
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Order canceled"})
 }
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pagination reads the page and limit query parameters. Missing or invalid
+// values fall back to the first page of defaultPageLimit items.
+func pagination(c *gin.Context) (page, limit int64) {
+	page, err := strconv.ParseInt(c.Query("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	return page, pageLimit(c)
+}
+
+// pageLimit reads the limit query parameter, capped at maxPageLimit.
+func pageLimit(c *gin.Context) int64 {
+	limit, err := strconv.ParseInt(c.Query("limit"), 10, 64)
+	if err != nil || limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return limit
+}
+
+func paginate(opts *options.FindOptions, page, limit int64) *options.FindOptions {
+	return opts.SetSkip((page - 1) * limit).SetLimit(limit)
+}
+
+func paginationMeta(page, limit, total int64) gin.H {
+	return gin.H{
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": (total + limit - 1) / limit,
+	}
+}
+
+// cursorMeta describes a page of a cursor-paginated listing. nextCursor is
+// empty on the last page.
+func cursorMeta(limit, total int64, nextCursor string) gin.H {
+	return gin.H{
+		"limit":      limit,
+		"total":      total,
+		"nextCursor": nextCursor,
+		"hasMore":    nextCursor != "",
+	}
+}
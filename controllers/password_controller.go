@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/mailer"
+	"ecommerce/models"
+	"ecommerce/passwd"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passwordResetInterval = time.Minute
+
+func ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	response := gin.H{"message": "If the account exists, a password reset email has been sent"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&user); err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var last models.PasswordReset
+	err := database.PasswordResetCollection.FindOne(ctx, bson.M{"userId": user.ID}).Decode(&last)
+	if err == nil && time.Since(last.CreatedAt) < passwordResetInterval {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Please wait before requesting another password reset email"})
+		return
+	}
+
+	if err := sendPasswordResetEmail(ctx, user); err != nil {
+		log.Println("⚠️  Failed to send password reset email:", err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func sendPasswordResetEmail(ctx context.Context, user models.User) error {
+	raw, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := config.GetEnvDuration("PASSWORD_RESET_TTL", time.Hour)
+	now := time.Now()
+	reset := models.PasswordReset{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+
+	// Requesting a new link invalidates any earlier one.
+	if _, err := database.PasswordResetCollection.DeleteMany(ctx, bson.M{"userId": user.ID}); err != nil {
+		return err
+	}
+	if _, err := database.PasswordResetCollection.InsertOne(ctx, reset); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.GetEnv("APP_BASE_URL", "http://localhost:8080"), url.QueryEscape(raw))
+
+	return mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s. If you did not request this, you can ignore this email.\n", user.Name, link, ttl),
+	})
+}
+
+func ResetPassword(c *gin.Context) {
+	var input struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"newPassword" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	// Look the account up before consuming the token so a rejected password
+	// does not burn the link.
+	var pending models.PasswordReset
+	err := database.PasswordResetCollection.FindOne(ctx, bson.M{
+		"tokenHash": hashToken(input.Token),
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": now},
+	}).Decode(&pending)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": pending.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+	if problems := passwordProblems(input.NewPassword, user.Email, user.Name); len(problems) > 0 {
+		respondFieldErrors(c, map[string][]string{"newPassword": problems})
+		return
+	}
+
+	var reset models.PasswordReset
+	err = database.PasswordResetCollection.FindOneAndUpdate(ctx,
+		bson.M{
+			"tokenHash": hashToken(input.Token),
+			"usedAt":    bson.M{"$exists": false},
+			"expiresAt": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"usedAt": now}},
+	).Decode(&reset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+
+	if err := setPassword(ctx, reset.UserID, input.NewPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please login again"})
+}
+
+func ChangePassword(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	var input struct {
+		CurrentPassword string `json:"currentPassword" binding:"required"`
+		NewPassword     string `json:"newPassword" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	if problems := passwordProblems(input.NewPassword, user.Email, user.Name); len(problems) > 0 {
+		respondFieldErrors(c, map[string][]string{"newPassword": problems})
+		return
+	}
+
+	if err := setPassword(ctx, user.ID, input.NewPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed, please login again"})
+}
+
+// passwordProblems checks a new password for the account. The breached
+// list failing to load is logged and does not block the user.
+func passwordProblems(password, email, name string) []string {
+	problems, err := passwd.Check(password, email, name)
+	if err != nil {
+		log.Println("⚠️  Failed to screen password against breach list:", err)
+	}
+	return problems
+}
+
+// respondFieldErrors answers 400 with the messages for each invalid field.
+func respondFieldErrors(c *gin.Context, fields map[string][]string) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "fields": fields})
+}
+
+// setPassword stores a new password hash and revokes every outstanding
+// token and reset link for the user.
+func setPassword(ctx context.Context, userID primitive.ObjectID, password string) error {
+	hashed, err := passwd.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.UserCollection.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"password": hashed}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := database.PasswordResetCollection.DeleteMany(ctx, bson.M{"userId": userID, "usedAt": bson.M{"$exists": false}}); err != nil {
+		return err
+	}
+
+	return revokeAllUserTokens(ctx, userID)
+}
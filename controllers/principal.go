@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"ecommerce/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUser returns the logged in user making the request. It answers
+// 401 itself when the request carries no user, such as an anonymous or API
+// key request on a misconfigured route.
+func currentUser(c *gin.Context) (middleware.Principal, bool) {
+	p, ok := middleware.GetPrincipal(c)
+	if !ok || p.IsAPIKey() || p.UserID.IsZero() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User authentication required"})
+		return middleware.Principal{}, false
+	}
+	return p, true
+}
@@ -4,21 +4,51 @@ import (
 	"context"
 	"ecommerce/database"
 	"ecommerce/models"
+	"ecommerce/search"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func CreateProduct(c *gin.Context) {
-	var product models.Product
-	if err := c.ShouldBindJSON(&product); err != nil {
+	// Price and stock are only required without variants; with variants
+	// they are derived from them.
+	var body struct {
+		models.Product
+		Price *float64 `json:"price"`
+		Stock *int     `json:"stock"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "All fields are required"})
 		return
 	}
+	product := body.Product
+	if len(product.Variants) == 0 {
+		fields := map[string][]string{}
+		switch {
+		case body.Price == nil:
+			fields["price"] = append(fields["price"], "Price is required")
+		case *body.Price <= 0:
+			fields["price"] = append(fields["price"], "Price must be above 0")
+		}
+		switch {
+		case body.Stock == nil:
+			fields["stock"] = append(fields["stock"], "Stock is required")
+		case *body.Stock < 0:
+			fields["stock"] = append(fields["stock"], "Stock cannot be negative")
+		}
+		if len(fields) > 0 {
+			respondFieldErrors(c, fields)
+			return
+		}
+		product.Price, product.Stock = *body.Price, *body.Stock
+	}
 
 	product.ID = primitive.NewObjectID()
 	product.CreatedAt = time.Now()
@@ -27,35 +57,56 @@ func CreateProduct(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if len(product.CategoryIDs) > 0 {
+		hexIDs := make([]string, len(product.CategoryIDs))
+		for i, id := range product.CategoryIDs {
+			hexIDs[i] = id.Hex()
+		}
+		ids, fields, err := parseCategoryIDs(ctx, hexIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+			return
+		}
+		if len(fields) > 0 {
+			respondFieldErrors(c, fields)
+			return
+		}
+		product.CategoryIDs = ids
+	}
+
+	if fields := applyVariants(&product, nil); len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return
+	}
+
 	_, err := database.ProductCollection.InsertOne(ctx, product)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "SKU already used by another product"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
 
+	if err := search.Default.Index(ctx, product); err != nil {
+		log.Println("⚠️  Failed to index product:", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Product created", "product": product})
 }
 
 func GetProductsAdmin(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	cursor, err := database.ProductCollection.Find(ctx, bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	var products []models.Product = []models.Product{}
-	if err := cursor.All(ctx, &products); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	products, meta, ok := listProducts(c, bson.M{})
+	if !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "Fetch products success",
-		"count":    len(products),
-		"products": products,
+		"message":    "Fetch products success",
+		"count":      len(products),
+		"products":   products,
+		"pagination": meta,
 	})
 
 }
@@ -93,14 +144,54 @@ func UpdateProduct(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	var updatedProduct models.Product
-	err := database.ProductCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{"$set": update}, opts).Decode(&updatedProduct)
+	// Price and stock of products with variants follow their variants.
+	filter := bson.M{"_id": objID}
+	if body.Price != nil || body.Stock != nil {
+		filter["variants.0"] = bson.M{"$exists": false}
+	}
+
+	// Read the previous version in the same operation so the audit diff
+	// matches exactly what this update replaced.
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	var previousProduct models.Product
+	err := database.ProductCollection.FindOneAndUpdate(ctx, filter, bson.M{"$set": update}, opts).Decode(&previousProduct)
+	if err == mongo.ErrNoDocuments && len(filter) > 1 {
+		if n, _ := database.ProductCollection.CountDocuments(ctx, bson.M{"_id": objID}); n > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Price and stock of this product are set per variant"})
+			return
+		}
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		return
 	}
 
+	updatedProduct := previousProduct
+	if body.Name != nil {
+		updatedProduct.Name = *body.Name
+	}
+	if body.Description != nil {
+		updatedProduct.Description = *body.Description
+	}
+	if body.Price != nil {
+		updatedProduct.Price = *body.Price
+	}
+	if body.Stock != nil {
+		updatedProduct.Stock = *body.Stock
+	}
+	updatedProduct.UpdatedAt = update["updatedAt"].(time.Time)
+
+	if err := search.Default.Index(ctx, updatedProduct); err != nil {
+		log.Println("⚠️  Failed to index product:", err)
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditProductUpdated,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		Changes:    auditDiff(previousProduct, updatedProduct),
+	})
+
 	c.JSON(http.StatusOK, updatedProduct)
 }
 
@@ -111,11 +202,30 @@ func DeleteProduct(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := database.ProductCollection.DeleteOne(ctx, bson.M{"_id": objID})
+	var deletedProduct models.Product
+	err := database.ProductCollection.FindOneAndDelete(ctx, bson.M{"_id": objID}).Decode(&deletedProduct)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
 	}
 
+	if err := search.Default.Remove(ctx, objID); err != nil {
+		log.Println("⚠️  Failed to remove product from search index:", err)
+	}
+	for _, image := range deletedProduct.Images {
+		deleteImageBlobs(ctx, image)
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditProductDeleted,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		Changes:    auditDiff(deletedProduct, bson.M{}),
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted", "id": id})
 }
@@ -1,31 +1,17 @@
 package controllers
 
 import (
-	"context"
-	"ecommerce/database"
-	"ecommerce/models"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
 func GetProductsPublic(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	cursor, err := database.ProductCollection.Find(ctx, bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	var products []models.Product
-	if err := cursor.All(ctx, &products); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	products, meta, ok := listProducts(c, bson.M{})
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": products})
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": products, "pagination": meta})
 }
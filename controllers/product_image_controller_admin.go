@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/blob"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/imaging"
+	"ecommerce/models"
+	"ecommerce/search"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxProductImages = 10
+	// A decoded image takes up to 4 bytes per pixel, so this keeps a single
+	// upload at about 64MB.
+	maxImagePixels = 16_000_000
+)
+
+func maxImageBytes() int64 {
+	return int64(config.GetEnvInt("IMAGE_MAX_BYTES", 5<<20))
+}
+
+// thumbnailSizes reads IMAGE_THUMBNAIL_SIZES, a comma-separated list of
+// the longer edge of each thumbnail in pixels.
+func thumbnailSizes() []int {
+	var sizes []int
+	for _, s := range strings.Split(config.GetEnv("IMAGE_THUMBNAIL_SIZES", "150,400,800"), ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n <= 0 {
+			log.Printf("⚠️  Ignoring invalid thumbnail size %q", s)
+			continue
+		}
+		sizes = append(sizes, n)
+	}
+	sort.Ints(sizes)
+	return sizes
+}
+
+// storeProductImage writes the original and its thumbnails to the blob
+// store. On error anything already written is removed again.
+func storeProductImage(ctx context.Context, productID primitive.ObjectID, data []byte) (models.ProductImage, error) {
+	img, contentType, err := imaging.Decode(data, maxImagePixels)
+	if err != nil {
+		return models.ProductImage{}, err
+	}
+
+	bounds := img.Bounds()
+	image := models.ProductImage{
+		ID:          primitive.NewObjectID(),
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Size:        len(data),
+		Thumbnails:  []models.ImageThumbnail{},
+		CreatedAt:   time.Now(),
+	}
+	prefix := fmt.Sprintf("products/%s/%s", productID.Hex(), image.ID.Hex())
+
+	image.Key = prefix + "/original." + imaging.Extension(contentType)
+	if err := blob.Put(ctx, image.Key, data, contentType); err != nil {
+		return models.ProductImage{}, err
+	}
+	image.URL = blob.URL(image.Key)
+
+	for _, size := range thumbnailSizes() {
+		thumb, ok := imaging.Thumbnail(img, size)
+		if !ok {
+			// Larger sizes would not be smaller than the original either.
+			break
+		}
+		encoded, thumbType, ext, err := imaging.Encode(thumb, contentType)
+		if err != nil {
+			deleteImageBlobs(ctx, image)
+			return models.ProductImage{}, err
+		}
+
+		key := fmt.Sprintf("%s/%d.%s", prefix, size, ext)
+		if err := blob.Put(ctx, key, encoded, thumbType); err != nil {
+			deleteImageBlobs(ctx, image)
+			return models.ProductImage{}, err
+		}
+		image.Thumbnails = append(image.Thumbnails, models.ImageThumbnail{
+			MaxEdge: size,
+			URL:     blob.URL(key),
+			Key:     key,
+			Width:   thumb.Bounds().Dx(),
+			Height:  thumb.Bounds().Dy(),
+		})
+	}
+
+	return image, nil
+}
+
+func deleteImageBlobs(ctx context.Context, image models.ProductImage) {
+	keys := []string{image.Key}
+	for _, t := range image.Thumbnails {
+		keys = append(keys, t.Key)
+	}
+	for _, key := range keys {
+		if err := blob.Delete(ctx, key); err != nil {
+			log.Println("⚠️  Failed to delete image blob:", err)
+		}
+	}
+}
+
+// UploadProductImage adds the image sent in the multipart field "image"
+// after the product's existing images.
+func UploadProductImage(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	limit := maxImageBytes()
+	// Leave room for the multipart framing around the file.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+64<<10)
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Image must be at most %d bytes", limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image file is required in the image field"})
+		return
+	}
+	if fileHeader.Size > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Image must be at most %d bytes", limit)})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var product models.Product
+	if err := database.ProductCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&product); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if len(product.Images) >= maxProductImages {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A product can have at most %d images", maxProductImages)})
+		return
+	}
+
+	image, err := storeProductImage(ctx, objID, data)
+	switch {
+	case err == imaging.ErrUnsupportedType:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Image must be a JPEG, PNG or GIF"})
+		return
+	case err == imaging.ErrTooManyPixels:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image dimensions are too large"})
+		return
+	case err != nil:
+		log.Println("⚠️  Failed to store product image:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store image"})
+		return
+	}
+
+	// The guard on the last slot keeps concurrent uploads within the limit.
+	filter := bson.M{"_id": objID, fmt.Sprintf("images.%d", maxProductImages-1): bson.M{"$exists": false}}
+	update := bson.M{"$push": bson.M{"images": image}, "$set": bson.M{"updatedAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updatedProduct models.Product
+	err = database.ProductCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedProduct)
+	if err != nil {
+		deleteImageBlobs(ctx, image)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A product can have at most %d images", maxProductImages)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add image"})
+		return
+	}
+
+	if err := search.Default.Index(ctx, updatedProduct); err != nil {
+		log.Println("⚠️  Failed to index product:", err)
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditProductUpdated,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		Metadata:   map[string]interface{}{"imageAdded": image.ID},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image uploaded", "data": image})
+}
+
+func DeleteProductImage(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	imageID, err := primitive.ObjectIDFromHex(c.Param("imageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	var previousProduct models.Product
+	err = database.ProductCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "images._id": imageID},
+		bson.M{"$pull": bson.M{"images": bson.M{"_id": imageID}}, "$set": bson.M{"updatedAt": time.Now()}},
+		opts,
+	).Decode(&previousProduct)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete image"})
+		return
+	}
+
+	updatedProduct := previousProduct
+	updatedProduct.Images = nil
+	for _, img := range previousProduct.Images {
+		if img.ID == imageID {
+			deleteImageBlobs(ctx, img)
+			continue
+		}
+		updatedProduct.Images = append(updatedProduct.Images, img)
+	}
+
+	if err := search.Default.Index(ctx, updatedProduct); err != nil {
+		log.Println("⚠️  Failed to index product:", err)
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditProductUpdated,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		Metadata:   map[string]interface{}{"imageDeleted": imageID},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted", "id": imageID.Hex()})
+}
+
+// ReorderProductImages sets the display order of the images. imageIds must
+// list every image of the product exactly once.
+func ReorderProductImages(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var body struct {
+		ImageIDs []string `json:"imageIds" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "imageIds is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var product models.Product
+	if err := database.ProductCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&product); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	byID := make(map[string]models.ProductImage, len(product.Images))
+	current := make([]primitive.ObjectID, 0, len(product.Images))
+	for _, img := range product.Images {
+		byID[img.ID.Hex()] = img
+		current = append(current, img.ID)
+	}
+
+	ordered := make([]models.ProductImage, 0, len(body.ImageIDs))
+	for _, id := range body.ImageIDs {
+		img, ok := byID[id]
+		if !ok {
+			respondFieldErrors(c, map[string][]string{"imageIds": {"Must list every image of the product exactly once"}})
+			return
+		}
+		delete(byID, id)
+		ordered = append(ordered, img)
+	}
+	if len(byID) > 0 {
+		respondFieldErrors(c, map[string][]string{"imageIds": {"Must list every image of the product exactly once"}})
+		return
+	}
+	if len(ordered) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Images reordered", "data": ordered})
+		return
+	}
+
+	// Only apply the order if the set of images has not changed meanwhile.
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updatedProduct models.Product
+	err = database.ProductCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "images._id": bson.M{"$all": current}, "images": bson.M{"$size": len(current)}},
+		bson.M{"$set": bson.M{"images": ordered, "updatedAt": time.Now()}},
+		opts,
+	).Decode(&updatedProduct)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusConflict, gin.H{"error": "Images changed while reordering, please retry"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder images"})
+		return
+	}
+
+	if err := search.Default.Index(ctx, updatedProduct); err != nil {
+		log.Println("⚠️  Failed to index product:", err)
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditProductUpdated,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		Changes:    auditDiff(product, updatedProduct),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Images reordered", "data": ordered})
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// productSortFields maps the sort query parameter to product fields. A
+// leading "-" sorts descending.
+var productSortFields = map[string]string{
+	"price":     "price",
+	"createdAt": "createdAt",
+	"name":      "name",
+}
+
+const defaultProductSort = "-createdAt"
+
+// productCursor marks the last product of a page. It carries the sort it
+// was issued for so it cannot be replayed against a different ordering.
+type productCursor struct {
+	Sort      string             `json:"s"`
+	ID        primitive.ObjectID `json:"id"`
+	Price     float64            `json:"p,omitempty"`
+	Name      string             `json:"n,omitempty"`
+	CreatedAt time.Time          `json:"c,omitempty"`
+}
+
+func (cur productCursor) value(field string) interface{} {
+	switch field {
+	case "price":
+		return cur.Price
+	case "name":
+		return cur.Name
+	default:
+		return cur.CreatedAt
+	}
+}
+
+func encodeProductCursor(sort string, p models.Product) string {
+	raw, _ := json.Marshal(productCursor{Sort: sort, ID: p.ID, Price: p.Price, Name: p.Name, CreatedAt: p.CreatedAt})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeProductCursor(s string) (productCursor, bool) {
+	var cur productCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(raw, &cur) != nil || cur.ID.IsZero() {
+		return productCursor{}, false
+	}
+	return cur, true
+}
+
+// listProducts reads the sort, filter and cursor query parameters and
+// returns one page of the products matching base. It writes the error response itself when
+// the query is invalid or the lookup fails.
+//
+// Supported parameters: sort (price, createdAt or name, "-" for
+// descending), minPrice, maxPrice, inStock, limit and cursor.
+func listProducts(c *gin.Context, base bson.M) ([]models.Product, gin.H, bool) {
+	fields := map[string][]string{}
+
+	sort := c.DefaultQuery("sort", defaultProductSort)
+	field, ok := productSortFields[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		fields["sort"] = append(fields["sort"], "Must be one of price, createdAt or name, optionally prefixed with -")
+	}
+	direction := 1
+	if strings.HasPrefix(sort, "-") {
+		direction = -1
+	}
+
+	filter := bson.M{}
+	for k, v := range base {
+		filter[k] = v
+	}
+	price := bson.M{}
+	for param, op := range map[string]string{"minPrice": "$gte", "maxPrice": "$lte"} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			fields[param] = append(fields[param], "Must be a non-negative number")
+			continue
+		}
+		price[op] = n
+	}
+	if min, ok := price["$gte"].(float64); ok {
+		if max, ok := price["$lte"].(float64); ok && min > max {
+			fields["maxPrice"] = append(fields["maxPrice"], "Must not be less than minPrice")
+		}
+	}
+	if len(price) > 0 {
+		filter["price"] = price
+	}
+
+	if v := c.Query("inStock"); v != "" {
+		inStock, err := strconv.ParseBool(v)
+		if err != nil {
+			fields["inStock"] = append(fields["inStock"], "Must be true or false")
+		} else if inStock {
+			filter["stock"] = bson.M{"$gt": 0}
+		}
+	}
+
+	// The count covers the whole filtered listing, so it is taken before
+	// the cursor narrows the query to the remaining pages.
+	countFilter := bson.M{}
+	for k, v := range filter {
+		countFilter[k] = v
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		cur, ok := decodeProductCursor(v)
+		switch {
+		case !ok:
+			fields["cursor"] = append(fields["cursor"], "Invalid cursor")
+		case cur.Sort != sort:
+			fields["cursor"] = append(fields["cursor"], "Cursor was issued for a different sort")
+		default:
+			op := "$gt"
+			if direction < 0 {
+				op = "$lt"
+			}
+			filter["$or"] = bson.A{
+				bson.M{field: bson.M{op: cur.value(field)}},
+				bson.M{field: cur.value(field), "_id": bson.M{op: cur.ID}},
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return nil, nil, false
+	}
+
+	limit := pageLimit(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	total, err := database.ProductCollection.CountDocuments(ctx, countFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	// Fetch one extra product to learn whether another page follows.
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(limit + 1)
+	cursor, err := database.ProductCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	var products []models.Product = []models.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	next := ""
+	if int64(len(products)) > limit {
+		products = products[:limit]
+		next = encodeProductCursor(sort, products[len(products)-1])
+	}
+
+	return products, cursorMeta(limit, total, next), true
+}
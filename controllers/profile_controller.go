@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/mailer"
+	"ecommerce/middleware"
+	"ecommerce/models"
+	"ecommerce/rbac"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func profileResponse(user models.User) gin.H {
+	return gin.H{
+		"id":            user.ID.Hex(),
+		"name":          user.Name,
+		"email":         user.Email,
+		"pendingEmail":  user.PendingEmail,
+		"role":          user.Role,
+		"emailVerified": user.EmailVerified,
+		"mfaEnabled":    user.MFAEnabled,
+		"identities":    user.Identities,
+		"createdAt":     user.CreatedAt,
+	}
+}
+
+func GetProfile(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": profileResponse(user)})
+}
+
+func UpdateProfile(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	var body struct {
+		Name            *string `json:"name"`
+		Email           *string `json:"email"`
+		CurrentPassword string  `json:"currentPassword"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	update := bson.M{}
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+			return
+		}
+		update["name"] = name
+	}
+
+	// The new address only replaces the current one once it is verified,
+	// until then it is kept as pendingEmail.
+	var newEmail string
+	if body.Email != nil && !strings.EqualFold(strings.TrimSpace(*body.Email), user.Email) {
+		newEmail = strings.TrimSpace(*body.Email)
+		if addr, err := mail.ParseAddress(newEmail); err != nil || addr.Address != newEmail {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+			return
+		}
+
+		if !reauthenticated(ctx, c, principal, user, body.CurrentPassword, "Current password is required to change email") {
+			return
+		}
+
+		taken, err := database.UserCollection.CountDocuments(ctx, bson.M{"email": newEmail})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+		if taken > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+			return
+		}
+
+		update["pendingEmail"] = newEmail
+	}
+
+	if len(update) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Nothing to update", "data": profileResponse(user)})
+		return
+	}
+
+	if _, err := database.UserCollection.UpdateOne(ctx, bson.M{"_id": objUserID}, bson.M{"$set": update}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+	if name, ok := update["name"].(string); ok {
+		user.Name = name
+	}
+
+	message := "Profile updated"
+	if newEmail != "" {
+		user.PendingEmail = newEmail
+		message = "Profile updated, please verify your new email address"
+
+		if err := sendVerificationEmail(ctx, user, newEmail); err != nil {
+			log.Println("⚠️  Failed to send verification email:", err)
+		}
+		if err := mailer.Send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Your email address is being changed",
+			Body:    fmt.Sprintf("Hi %s,\n\nA request was made to change the email address of your account to %s. If this was not you, please reset your password right away.\n", user.Name, newEmail),
+		}); err != nil {
+			log.Println("⚠️  Failed to send email change notice:", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": profileResponse(user)})
+}
+
+// DeleteAccount anonymizes the user instead of removing the document so
+// their orders stay intact for accounting.
+// reauthenticated confirms a sensitive change is made by the account owner
+// and not just by whoever holds an access token. Users with a password must
+// send it; users who only sign in through OIDC must have signed in within
+// REAUTH_MAX_AGE (default 5m). It answers 401 itself otherwise.
+func reauthenticated(ctx context.Context, c *gin.Context, principal middleware.Principal, user models.User, password, wrongPassword string) bool {
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": wrongPassword})
+			return false
+		}
+		return true
+	}
+
+	// A session starts at login and keeps its creation time across token
+	// refreshes, so it tells when the user last actually signed in.
+	var session models.Session
+	err := database.SessionCollection.FindOne(ctx, bson.M{"_id": principal.SessionID, "userId": user.ID}).Decode(&session)
+	if err != nil || time.Since(session.CreatedAt) > config.GetEnvDuration("REAUTH_MAX_AGE", 5*time.Minute) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Please sign in again to confirm this change", "reauthRequired": true})
+		return false
+	}
+	return true
+}
+
+func DeleteAccount(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	var body struct {
+		Password string `json:"password"`
+	}
+	_ = c.ShouldBindJSON(&body)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objUserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if rbac.IsStaff(user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Staff accounts must be demoted before they can be deleted"})
+		return
+	}
+	if !reauthenticated(ctx, c, principal, user, body.Password, "Password is incorrect") {
+		return
+	}
+
+	now := time.Now()
+	_, err := database.UserCollection.UpdateOne(ctx, bson.M{"_id": objUserID}, bson.M{
+		"$set": bson.M{
+			"name":          "Deleted user",
+			"email":         fmt.Sprintf("deleted-%s@deleted.invalid", user.ID.Hex()),
+			"password":      "",
+			"emailVerified": false,
+			"mfaEnabled":    false,
+			"deletedAt":     now,
+		},
+		"$unset": bson.M{
+			"pendingEmail":     "",
+			"emailVerifiedAt":  "",
+			"mfaSecret":        "",
+			"mfaPendingSecret": "",
+			"mfaLastStep":      "",
+			"mfaRecoveryCodes": "",
+			"identities":       "",
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	if err := revokeAllUserTokens(ctx, objUserID); err != nil {
+		log.Println("⚠️  Failed to revoke tokens of deleted user:", err)
+	}
+
+	_, _ = database.CartCollection.DeleteMany(ctx, bson.M{"userId": objUserID})
+	_, _ = database.AddressCollection.DeleteMany(ctx, bson.M{"userId": objUserID})
+	_, _ = database.DataExportCollection.DeleteMany(ctx, bson.M{"userId": objUserID})
+	_, _ = database.SessionCollection.DeleteMany(ctx, bson.M{"userId": objUserID})
+	_, _ = database.EmailVerificationCollection.DeleteMany(ctx, bson.M{"userId": objUserID})
+	_, _ = database.PasswordResetCollection.DeleteMany(ctx, bson.M{"userId": objUserID})
+	_, _ = database.AccountUnlockCollection.DeleteMany(ctx, bson.M{"userId": objUserID})
+	_, _ = database.LoginAttemptCollection.DeleteOne(ctx, bson.M{"key": emailAttemptKey(user.Email)})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/rbac"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{2,32}$`)
+
+// validatePermissions responds with an error unless every permission exists
+// and is held by the acting user, so staff cannot grant themselves more
+// through a custom role.
+func validatePermissions(c *gin.Context, perms []string) bool {
+	actor, ok := currentUser(c)
+	if !ok {
+		return false
+	}
+	for _, p := range perms {
+		if !rbac.ValidPermission(p) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid permission: " + p, "allowedPermissions": models.Permissions})
+			return false
+		}
+		if !rbac.Has(actor.Role, p) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot grant a permission you do not have: " + p})
+			return false
+		}
+	}
+	return true
+}
+
+func GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": models.Permissions})
+}
+
+func GetRoles(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := database.RoleCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var roles []models.Role = []models.Role{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": roles})
+}
+
+func CreateRole(c *gin.Context) {
+	var body struct {
+		Name        string   `json:"name" binding:"required"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if !roleNamePattern.MatchString(body.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role name must be 2-32 characters of a-z, 0-9, _ or -"})
+		return
+	}
+	if !validatePermissions(c, body.Permissions) {
+		return
+	}
+
+	now := time.Now()
+	role := models.Role{
+		ID:          primitive.NewObjectID(),
+		Name:        body.Name,
+		Description: body.Description,
+		Permissions: body.Permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := database.RoleCollection.InsertOne(ctx, role); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Role already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create role"})
+		return
+	}
+	_ = rbac.Reload(ctx)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role created", "data": role})
+}
+
+func UpdateRole(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role ID"})
+		return
+	}
+
+	var body struct {
+		Description *string  `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	if body.Description != nil {
+		set["description"] = *body.Description
+	}
+	if body.Permissions != nil {
+		if !validatePermissions(c, body.Permissions) {
+			return
+		}
+		set["permissions"] = body.Permissions
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var role models.Role
+	if err := database.RoleCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&role); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+	if role.BuiltIn {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Built-in roles cannot be changed"})
+		return
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Role
+	err = database.RoleCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{"$set": set}, opts).Decode(&updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	_ = rbac.Reload(ctx)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated", "data": updated})
+}
+
+func DeleteRole(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var role models.Role
+	if err := database.RoleCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&role); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+	if role.BuiltIn {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Built-in roles cannot be deleted"})
+		return
+	}
+
+	assigned, err := database.UserCollection.CountDocuments(ctx, bson.M{"role": role.Name})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+	if assigned > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Role is still assigned to users"})
+		return
+	}
+
+	if _, err := database.RoleCollection.DeleteOne(ctx, bson.M{"_id": objID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+	_ = rbac.Reload(ctx)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted"})
+}
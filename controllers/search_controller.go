@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/search"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxSearchQueryLength = 100
+	defaultSuggestLimit  = 10
+	maxSuggestLimit      = 20
+)
+
+// searchQuery reads the q parameter, responding with a field error when it
+// is missing or too long.
+func searchQuery(c *gin.Context) (string, bool) {
+	q := strings.TrimSpace(c.Query("q"))
+	switch {
+	case q == "":
+		respondFieldErrors(c, map[string][]string{"q": {"Search query is required"}})
+		return "", false
+	case utf8.RuneCountInString(q) > maxSearchQueryLength:
+		respondFieldErrors(c, map[string][]string{"q": {"Search query must be at most 100 characters"}})
+		return "", false
+	}
+	return q, true
+}
+
+func SearchProducts(c *gin.Context) {
+	q, ok := searchQuery(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results, err := search.Default.Search(ctx, q, int(pageLimit(c)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": results})
+}
+
+func AutocompleteProducts(c *gin.Context) {
+	q, ok := searchQuery(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultSuggestLimit
+	}
+	if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	suggestions, err := search.Default.Suggest(ctx, q, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Autocomplete failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": suggestions})
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/auth"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/revocation"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func createSession(ctx context.Context, c *gin.Context, userID primitive.ObjectID) (models.Session, error) {
+	now := time.Now()
+	userAgent := c.Request.UserAgent()
+	session := models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     userID,
+		Device:     describeDevice(userAgent),
+		UserAgent:  userAgent,
+		IP:         c.ClientIP(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL()),
+	}
+
+	_, err := database.SessionCollection.InsertOne(ctx, session)
+	return session, err
+}
+
+// touchSession records activity on a session. It is called on every token
+// refresh, so last-seen is accurate to roughly one access token lifetime.
+func touchSession(ctx context.Context, c *gin.Context, sessionID primitive.ObjectID) {
+	now := time.Now()
+	_, _ = database.SessionCollection.UpdateOne(ctx,
+		bson.M{"_id": sessionID},
+		bson.M{"$set": bson.M{
+			"lastSeenAt": now,
+			"ip":         c.ClientIP(),
+			"expiresAt":  now.Add(refreshTokenTTL()),
+		}},
+	)
+}
+
+// revokeSession ends one session of the user: its refresh tokens stop
+// working and its access tokens are rejected by AuthMiddleware.
+func revokeSession(ctx context.Context, sessionID, userID primitive.ObjectID) error {
+	now := time.Now()
+	result, err := database.SessionCollection.UpdateOne(ctx,
+		bson.M{"_id": sessionID, "userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := revokeRefreshFamily(ctx, sessionID); err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return nil
+	}
+	return revocation.Default.RevokeSession(ctx, sessionID.Hex(), userID.Hex(), now.Add(auth.AccessTokenTTL()))
+}
+
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "okhttp"), strings.Contains(ua, "dart"), strings.Contains(ua, "cfnetwork"):
+		browser = "Mobile app"
+	case strings.Contains(ua, "curl/"), strings.Contains(ua, "postman"):
+		browser = "API client"
+	}
+
+	os := "unknown OS"
+	switch {
+	case strings.Contains(ua, "android"):
+		os = "Android"
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ios"):
+		os = "iOS"
+	case strings.Contains(ua, "windows"):
+		os = "Windows"
+	case strings.Contains(ua, "mac os"), strings.Contains(ua, "macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "linux"):
+		os = "Linux"
+	}
+
+	return browser + " on " + os
+}
+
+func GetSessions(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+	currentSession := principal.SessionID.Hex()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "lastSeenAt", Value: -1}})
+	cursor, err := database.SessionCollection.Find(ctx, bson.M{
+		"userId":    objUserID,
+		"revokedAt": bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := []gin.H{}
+	for _, s := range sessions {
+		resp = append(resp, gin.H{
+			"id":         s.ID.Hex(),
+			"device":     s.Device,
+			"userAgent":  s.UserAgent,
+			"ip":         s.IP,
+			"createdAt":  s.CreatedAt,
+			"lastSeenAt": s.LastSeenAt,
+			"current":    s.ID.Hex() == currentSession,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": resp})
+}
+
+func DeleteSession(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := database.SessionCollection.CountDocuments(ctx, bson.M{
+		"_id":       sessionID,
+		"userId":    objUserID,
+		"revokedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := revokeSession(ctx, sessionID, objUserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked", "id": sessionID.Hex()})
+}
+
+func LogoutEverywhere(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	objUserID := principal.UserID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := revokeAllUserTokens(ctx, objUserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out from all devices"})
+}
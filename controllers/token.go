@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"ecommerce/auth"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/revocation"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errRefreshTokenInvalid = errors.New("invalid refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+func refreshTokenTTL() time.Duration {
+	return config.GetEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// generateAccessToken signs a short-lived access token for the session. mfa
+// records whether the session passed a second factor, which
+// RequirePermission may require of staff.
+func generateAccessToken(user models.User, sessionID primitive.ObjectID, mfa bool) (string, error) {
+	return auth.Sign(auth.Claims{
+		UserID:    user.ID.Hex(),
+		Role:      user.Role,
+		SessionID: sessionID.Hex(),
+		MFA:       mfa,
+	}, auth.AccessTokenTTL())
+}
+
+// rejectSuspended responds with 403 and returns true if staff suspended the
+// account.
+func rejectSuspended(c *gin.Context, user models.User) bool {
+	if user.SuspendedAt == nil {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been suspended"})
+	return true
+}
+
+// respondWithTokens completes a login by starting a session for the
+// requesting device and issuing its access and refresh token pair. A guest
+// cart sent along with the request is merged into the user's cart. It
+// returns whether tokens were issued.
+func respondWithTokens(ctx context.Context, c *gin.Context, user models.User, mfa bool) bool {
+	if rejectSuspended(c, user) {
+		return false
+	}
+
+	session, err := createSession(ctx, c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return false
+	}
+
+	tokenString, err := generateAccessToken(user, session.ID, mfa)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return false
+	}
+
+	refreshToken, _, err := issueRefreshToken(ctx, user.ID, session.ID, mfa)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		return false
+	}
+
+	response := gin.H{
+		"user": gin.H{
+			"id":           user.ID.Hex(),
+			"name":         user.Name,
+			"email":        user.Email,
+			"role":         user.Role,
+			"token":        tokenString,
+			"refreshToken": refreshToken,
+			"expiresIn":    int64(auth.AccessTokenTTL().Seconds()),
+		},
+	}
+	if adjustments := mergeGuestCart(ctx, c, user.ID); adjustments != nil {
+		response["cart"] = gin.H{"merged": true, "adjustments": adjustments}
+	}
+
+	c.JSON(http.StatusOK, response)
+	return true
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// issueRefreshToken stores the first refresh token of a session and returns
+// the raw value.
+func issueRefreshToken(ctx context.Context, userID, sessionID primitive.ObjectID, mfa bool) (string, models.RefreshToken, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	now := time.Now()
+	rt := models.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		FamilyID:  sessionID,
+		TokenHash: hashToken(raw),
+		MFA:       mfa,
+		ExpiresAt: now.Add(refreshTokenTTL()),
+		CreatedAt: now,
+	}
+
+	if _, err := database.RefreshTokenCollection.InsertOne(ctx, rt); err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	return raw, rt, nil
+}
+
+// rotateRefreshToken consumes raw and returns a fresh token in the same
+// family. Presenting a token that was already rotated or revoked revokes
+// every token in its family.
+func rotateRefreshToken(ctx context.Context, raw string) (string, models.RefreshToken, error) {
+	var current models.RefreshToken
+	err := database.RefreshTokenCollection.FindOne(ctx, bson.M{"tokenHash": hashToken(raw)}).Decode(&current)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", models.RefreshToken{}, errRefreshTokenInvalid
+		}
+		return "", models.RefreshToken{}, err
+	}
+
+	if current.RevokedAt != nil {
+		_ = revokeSession(ctx, current.FamilyID, current.UserID)
+		return "", models.RefreshToken{}, errRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return "", models.RefreshToken{}, errRefreshTokenInvalid
+	}
+
+	nextID := primitive.NewObjectID()
+	now := time.Now()
+
+	// Only one concurrent request may win the rotation; the loser is treated
+	// as a replay.
+	res := database.RefreshTokenCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": current.ID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now, "replacedBy": nextID}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if err := res.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			_ = revokeSession(ctx, current.FamilyID, current.UserID)
+			return "", models.RefreshToken{}, errRefreshTokenReused
+		}
+		return "", models.RefreshToken{}, err
+	}
+
+	next, err := randomToken()
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	rt := models.RefreshToken{
+		ID:        nextID,
+		UserID:    current.UserID,
+		FamilyID:  current.FamilyID,
+		TokenHash: hashToken(next),
+		MFA:       current.MFA,
+		ExpiresAt: now.Add(refreshTokenTTL()),
+		CreatedAt: now,
+	}
+	if _, err := database.RefreshTokenCollection.InsertOne(ctx, rt); err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	return next, rt, nil
+}
+
+func revokeRefreshFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	_, err := database.RefreshTokenCollection.UpdateMany(ctx,
+		bson.M{"familyId": familyID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	return err
+}
+
+// revokeAllUserTokens ends every session of the user and invalidates every
+// access token issued before now. The revocations take effect on this
+// instance before it returns; other instances pick them up within
+// REVOCATION_REFRESH_INTERVAL.
+func revokeAllUserTokens(ctx context.Context, userID primitive.ObjectID) error {
+	now := time.Now()
+
+	_, err := database.RefreshTokenCollection.UpdateMany(ctx,
+		bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := database.SessionCollection.Find(ctx,
+		bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return err
+	}
+
+	_, err = database.SessionCollection.UpdateMany(ctx,
+		bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// The per-user cutoff only has whole seconds, so tokens issued earlier
+	// in the current second still pass it; revoking their sessions by sid
+	// covers those.
+	expiresAt := now.Add(auth.AccessTokenTTL())
+	for _, session := range sessions {
+		if err := revocation.Default.RevokeSession(ctx, session.ID.Hex(), userID.Hex(), expiresAt); err != nil {
+			return err
+		}
+	}
+
+	return revocation.Default.RevokeUser(ctx, userID.Hex(), now)
+}
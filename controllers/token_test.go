@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/revocation"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// useTestMongo points the collections at a scratch database on MONGO_URI
+// that is dropped after the test. Tests needing Mongo skip without it.
+func useTestMongo(t *testing.T) {
+	t.Helper()
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevDB, prevClient := database.DB, database.Client
+	database.Client = client
+	database.DB = client.Database("ecommerce_test_" + primitive.NewObjectID().Hex())
+	database.InitCollections()
+
+	prevStore := revocation.Default
+	revocation.Default = revocation.NewStore(nil, nil, time.Hour)
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = database.DB.Drop(ctx)
+		_ = client.Disconnect(ctx)
+		database.DB, database.Client = prevDB, prevClient
+		if prevDB != nil {
+			database.InitCollections()
+		}
+		revocation.Default = prevStore
+	})
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	useTestMongo(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	session := models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     primitive.NewObjectID(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+	if _, err := database.SessionCollection.InsertOne(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _, err := issueRefreshToken(ctx, session.UserID, session.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := rotateRefreshToken(ctx, first)
+	if err != nil {
+		t.Fatalf("first rotation: %v", err)
+	}
+
+	// Replaying the rotated token must end the whole session.
+	if _, _, err := rotateRefreshToken(ctx, first); err != errRefreshTokenReused {
+		t.Fatalf("replaying a rotated token: got %v, want %v", err, errRefreshTokenReused)
+	}
+	if _, _, err := rotateRefreshToken(ctx, second); err != errRefreshTokenReused {
+		t.Fatalf("rotating the current token after a replay: got %v, want %v", err, errRefreshTokenReused)
+	}
+
+	active, err := database.RefreshTokenCollection.CountDocuments(ctx, bson.M{"familyId": session.ID, "revokedAt": bson.M{"$exists": false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active != 0 {
+		t.Errorf("%d refresh tokens of the family still active", active)
+	}
+
+	var stored models.Session
+	if err := database.SessionCollection.FindOne(ctx, bson.M{"_id": session.ID}).Decode(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.RevokedAt == nil {
+		t.Error("session not revoked")
+	}
+	if !revocation.Default.IsRevoked("jti", session.ID.Hex(), session.UserID.Hex(), now) {
+		t.Error("access tokens of the session not revoked")
+	}
+}
@@ -0,0 +1,487 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/rbac"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GrantRole(c *gin.Context) {
+	var body struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if !rbac.Exists(body.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role value"})
+		return
+	}
+
+	changeRole(c, body.Role, models.RoleActionGrant)
+}
+
+func RevokeRole(c *gin.Context) {
+	changeRole(c, models.RoleCustomer, models.RoleActionRevoke)
+}
+
+func changeRole(c *gin.Context, role, action string) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	actor, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	actorID := actor.UserID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.Role == role {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User already has role " + role})
+		return
+	}
+
+	// Staff may only move users between roles they fully hold themselves.
+	if !rbac.Covers(actor.Role, role) || !rbac.Covers(actor.Role, user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot assign or remove a role with permissions you do not have"})
+		return
+	}
+
+	if user.Role == models.RoleAdmin {
+		if objID == actorID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot remove your own admin role"})
+			return
+		}
+
+		last, err := isLastActiveAdmin(ctx, user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+			return
+		}
+		if last {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove the last admin"})
+			return
+		}
+	}
+
+	// Match on the role we just read so concurrent changes don't get
+	// recorded against the wrong previous role.
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updatedUser models.User
+	err = database.UserCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "role": user.Role},
+		bson.M{"$set": bson.M{"role": role}},
+		opts,
+	).Decode(&updatedUser)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User role changed concurrently, please retry"})
+		return
+	}
+
+	audit := models.RoleAudit{
+		ID:           primitive.NewObjectID(),
+		UserID:       objID,
+		ActorID:      actorID,
+		Action:       action,
+		PreviousRole: user.Role,
+		Role:         role,
+		CreatedAt:    time.Now(),
+	}
+	if _, err := database.RoleAuditCollection.InsertOne(ctx, audit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Role updated but failed to record audit"})
+		return
+	}
+
+	// Access tokens carry the role, so end the user's sessions to apply the
+	// new permissions right away.
+	if err := revokeAllUserTokens(ctx, objID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Role updated but failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User role updated",
+		"data": gin.H{
+			"id":    updatedUser.ID.Hex(),
+			"name":  updatedUser.Name,
+			"email": updatedUser.Email,
+			"role":  updatedUser.Role,
+		},
+	})
+}
+
+// isLastActiveAdmin reports whether user is the only admin who can still
+// log in. Suspended and deleted admins do not count.
+func isLastActiveAdmin(ctx context.Context, user models.User) (bool, error) {
+	if user.Role != models.RoleAdmin || user.SuspendedAt != nil || user.DeletedAt != nil {
+		return false, nil
+	}
+	admins, err := database.UserCollection.CountDocuments(ctx, bson.M{
+		"role":        models.RoleAdmin,
+		"suspendedAt": bson.M{"$exists": false},
+		"deletedAt":   bson.M{"$exists": false},
+	})
+	if err != nil {
+		return false, err
+	}
+	return admins <= 1, nil
+}
+
+func GetRoleAudits(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := database.RoleAuditCollection.Find(ctx, bson.M{"userId": objID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var audits []models.RoleAudit = []models.RoleAudit{}
+	if err := cursor.All(ctx, &audits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fetch success", "data": audits})
+}
+
+func UnlockUser(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := clearLoginFailures(ctx, emailAttemptKey(user.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+	_, _ = database.AccountUnlockCollection.DeleteMany(ctx, bson.M{"userId": objID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+// spendStatuses are the order statuses that count toward a user's lifetime
+// spend. Pending, canceled and refunded orders are left out.
+var spendStatuses = []string{"paid", "delivered", "completed"}
+
+// parseDateParam accepts RFC 3339 timestamps or plain dates. A plain date
+// used as an upper bound covers the whole day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+func GetUsers(c *gin.Context) {
+	filter := bson.M{}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(q), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"email": pattern}}
+	}
+	if role := c.Query("role"); role != "" {
+		filter["role"] = role
+	}
+
+	switch c.Query("status") {
+	case "":
+	case "active":
+		filter["suspendedAt"] = bson.M{"$exists": false}
+		filter["deletedAt"] = bson.M{"$exists": false}
+	case "suspended":
+		filter["suspendedAt"] = bson.M{"$exists": true}
+	case "deleted":
+		filter["deletedAt"] = bson.M{"$exists": true}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active, suspended or deleted"})
+		return
+	}
+
+	createdAt := bson.M{}
+	if from := c.Query("createdFrom"); from != "" {
+		t, err := parseDateParam(from, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid createdFrom date"})
+			return
+		}
+		createdAt["$gte"] = t
+	}
+	if to := c.Query("createdTo"); to != "" {
+		t, err := parseDateParam(to, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid createdTo date"})
+			return
+		}
+		createdAt["$lte"] = t
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	page, limit := pagination(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	total, err := database.UserCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := paginate(options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}), page, limit)
+	cursor, err := database.UserCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var users []models.User = []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Fetch success",
+		"data":       users,
+		"pagination": paginationMeta(page, limit, total),
+	})
+}
+
+func GetUserByID(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userId": objID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        nil,
+			"orderCount": bson.M{"$sum": 1},
+			"lifetimeSpend": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$in": bson.A{"$status", spendStatuses}}, "$total", 0},
+			}},
+			"lastOrderAt": bson.M{"$max": "$createdAt"},
+		}}},
+	}
+	cursor, err := database.OrderCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var stats []struct {
+		OrderCount    int64   `bson:"orderCount"`
+		LifetimeSpend float64 `bson:"lifetimeSpend"`
+		LastOrderAt   int64   `bson:"lastOrderAt"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary := gin.H{"orderCount": int64(0), "lifetimeSpend": float64(0), "lastOrderAt": nil}
+	if len(stats) > 0 {
+		summary["orderCount"] = stats[0].OrderCount
+		summary["lifetimeSpend"] = stats[0].LifetimeSpend
+		summary["lastOrderAt"] = stats[0].LastOrderAt
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Fetch success",
+		"data": gin.H{
+			"user":   user,
+			"orders": summary,
+		},
+	})
+}
+
+// SuspendUser blocks the account from logging in and revokes its sessions
+// and access tokens. This instance rejects the user's requests right away;
+// other instances do after their next revocation refresh, at most
+// REVOCATION_REFRESH_INTERVAL later.
+func SuspendUser(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&body)
+
+	actor, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if objID == actor.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot suspend your own account"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.DeletedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User account has been deleted"})
+		return
+	}
+	if !rbac.Covers(actor.Role, user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot suspend a user with permissions you do not have"})
+		return
+	}
+	last, err := isLastActiveAdmin(ctx, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+	if last {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot suspend the last admin"})
+		return
+	}
+
+	result, err := database.UserCollection.UpdateOne(ctx,
+		bson.M{"_id": objID, "suspendedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"suspendedAt": time.Now(), "suspendedReason": body.Reason}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User is already suspended"})
+		return
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditUserSuspended,
+		TargetType: models.AuditTargetUser,
+		TargetID:   objID,
+		Metadata:   gin.H{"reason": body.Reason},
+	})
+
+	if err := revokeAllUserTokens(ctx, objID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User suspended but failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User suspended"})
+}
+
+func UnsuspendUser(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	actor, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !rbac.Covers(actor.Role, user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot unsuspend a user with permissions you do not have"})
+		return
+	}
+
+	// Match on the role just checked so a concurrent promotion cannot slip
+	// past it.
+	result, err := database.UserCollection.UpdateOne(ctx,
+		bson.M{"_id": objID, "role": user.Role, "suspendedAt": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"suspendedAt": "", "suspendedReason": ""}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsuspend user"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found or not suspended"})
+		return
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditUserUnsuspended,
+		TargetType: models.AuditTargetUser,
+		TargetID:   objID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unsuspended"})
+}
@@ -0,0 +1,422 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/search"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	errVariantRequired   = errors.New("variant required")
+	errVariantNotFound   = errors.New("variant not found")
+	errVariantNotAllowed = errors.New("product has no variants")
+)
+
+// purchasable is what a cart line or order item buys: a product without
+// variants, or one variant of a product.
+type purchasable struct {
+	Product models.Product
+	Variant *models.ProductVariant
+}
+
+func (p purchasable) Price() float64 {
+	if p.Variant != nil {
+		return p.Variant.Price
+	}
+	return p.Product.Price
+}
+
+func (p purchasable) Stock() int {
+	if p.Variant != nil {
+		return p.Variant.Stock
+	}
+	return p.Product.Stock
+}
+
+func (p purchasable) VariantID() primitive.ObjectID {
+	if p.Variant != nil {
+		return p.Variant.ID
+	}
+	return primitive.NilObjectID
+}
+
+// details describes the line for cart and order responses.
+func (p purchasable) details() gin.H {
+	h := gin.H{"name": p.Product.Name, "price": p.Price(), "stock": p.Stock()}
+	if p.Variant != nil {
+		h["variantId"] = p.Variant.ID
+		h["sku"] = p.Variant.SKU
+		h["options"] = p.Variant.Options
+	}
+	return h
+}
+
+// orderItem snapshots the line into an order item.
+func (p purchasable) orderItem(quantity int) models.OrderItem {
+	item := models.OrderItem{ProductID: p.Product.ID, Quantity: quantity, Price: p.Price()}
+	if p.Variant != nil {
+		item.VariantID = p.Variant.ID
+		item.SKU = p.Variant.SKU
+		item.Options = p.Variant.Options
+	}
+	return item
+}
+
+// findPurchasable loads a product and, for products with variants, the
+// chosen variant. It returns mongo.ErrNoDocuments for a missing product,
+// errVariantRequired when a variant must be chosen, errVariantNotFound
+// for an unknown variant and errVariantNotAllowed when a variant was given
+// for a product without any. With the variant errors the product is still
+// returned.
+func findPurchasable(ctx context.Context, productID, variantID primitive.ObjectID) (purchasable, error) {
+	var product models.Product
+	if err := database.ProductCollection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product); err != nil {
+		return purchasable{}, err
+	}
+
+	if len(product.Variants) == 0 {
+		if !variantID.IsZero() {
+			return purchasable{Product: product}, errVariantNotAllowed
+		}
+		return purchasable{Product: product}, nil
+	}
+
+	if variantID.IsZero() {
+		return purchasable{Product: product}, errVariantRequired
+	}
+	variant, ok := product.Variant(variantID)
+	if !ok {
+		return purchasable{Product: product}, errVariantNotFound
+	}
+	return purchasable{Product: product, Variant: &variant}, nil
+}
+
+// respondPurchasableError writes the response for an error returned by
+// findPurchasable.
+func respondPurchasableError(c *gin.Context, err error) {
+	switch err {
+	case mongo.ErrNoDocuments:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errVariantRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please choose a variant of this product"})
+	case errVariantNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+	case errVariantNotAllowed:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This product has no variants"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product"})
+	}
+}
+
+// unavailableReason explains why a cart line returned err from
+// findPurchasable, or returns false for errors that are not about the line.
+func unavailableReason(err error) (string, bool) {
+	switch err {
+	case mongo.ErrNoDocuments:
+		return "Product is no longer available", true
+	case errVariantRequired:
+		return "Product now comes in variants, please choose one", true
+	case errVariantNotFound:
+		return "This variant is no longer available", true
+	case errVariantNotAllowed:
+		return "This product no longer has variants", true
+	}
+	return "", false
+}
+
+// parseVariantID reads an optional variant ID; an empty string means none.
+func parseVariantID(s string) (primitive.ObjectID, bool) {
+	if s == "" {
+		return primitive.NilObjectID, true
+	}
+	id, err := primitive.ObjectIDFromHex(s)
+	return id, err == nil
+}
+
+// stockFilter selects the product, or the product holding the variant,
+// as long as at least quantity is in stock.
+func stockFilter(productID, variantID primitive.ObjectID, quantity int) bson.M {
+	if variantID.IsZero() {
+		return bson.M{"_id": productID, "stock": bson.M{"$gte": quantity}}
+	}
+	return bson.M{
+		"_id":      productID,
+		"variants": bson.M{"$elemMatch": bson.M{"_id": variantID, "stock": bson.M{"$gte": quantity}}},
+	}
+}
+
+// stockChange adds delta to the stock of the product or variant. The
+// product total moves with the variant so listings stay accurate.
+func stockChange(variantID primitive.ObjectID, delta int) bson.M {
+	if variantID.IsZero() {
+		return bson.M{"$inc": bson.M{"stock": delta}}
+	}
+	return bson.M{"$inc": bson.M{"stock": delta, "variants.$.stock": delta}}
+}
+
+// takeStock decrements stock if enough is available. It returns false when
+// there is not.
+func takeStock(ctx context.Context, productID, variantID primitive.ObjectID, quantity int) (bool, error) {
+	result, err := database.ProductCollection.UpdateOne(ctx, stockFilter(productID, variantID, quantity), stockChange(variantID, -quantity))
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// returnStock puts stock taken by takeStock back.
+func returnStock(ctx context.Context, productID, variantID primitive.ObjectID, quantity int) error {
+	filter := bson.M{"_id": productID}
+	if !variantID.IsZero() {
+		filter["variants._id"] = variantID
+	}
+	_, err := database.ProductCollection.UpdateOne(ctx, filter, stockChange(variantID, quantity))
+	return err
+}
+
+// applyVariants checks the options and variants set on product and fills
+// in derived fields: new variant IDs, the product price and stock. Variants
+// keep their ID when it matches one in existing. It returns field errors.
+func applyVariants(product *models.Product, existing []models.ProductVariant) map[string][]string {
+	fields := map[string][]string{}
+	addErr := func(field, msg string) { fields[field] = append(fields[field], msg) }
+
+	allowed := map[string]map[string]bool{}
+	for i := range product.Options {
+		opt := &product.Options[i]
+		opt.Name = strings.TrimSpace(opt.Name)
+		if opt.Name == "" {
+			addErr("options", "Option name is required")
+			continue
+		}
+		if allowed[opt.Name] != nil {
+			addErr("options", "Duplicate option "+opt.Name)
+			continue
+		}
+		if len(opt.Values) == 0 {
+			addErr("options", "Option "+opt.Name+" needs at least one value")
+		}
+		values := map[string]bool{}
+		for j, v := range opt.Values {
+			v = strings.TrimSpace(v)
+			opt.Values[j] = v
+			if v == "" || values[v] {
+				addErr("options", "Option "+opt.Name+" has an empty or duplicate value")
+				continue
+			}
+			values[v] = true
+		}
+		allowed[opt.Name] = values
+	}
+
+	switch {
+	case len(product.Options) > 0 && len(product.Variants) == 0:
+		addErr("variants", "At least one variant is required when options are set")
+	case len(product.Options) == 0 && len(product.Variants) > 0:
+		addErr("options", "Options are required when variants are set")
+	}
+
+	known := map[primitive.ObjectID]bool{}
+	for _, v := range existing {
+		known[v.ID] = true
+	}
+
+	skus := map[string]bool{}
+	combinations := map[string]bool{}
+	for i := range product.Variants {
+		v := &product.Variants[i]
+		label := fmt.Sprintf("Variant %d", i+1)
+
+		if v.ID.IsZero() {
+			v.ID = primitive.NewObjectID()
+		} else if !known[v.ID] {
+			addErr("variants", label+" has an unknown id")
+		}
+
+		v.SKU = strings.TrimSpace(v.SKU)
+		switch {
+		case v.SKU == "":
+			addErr("variants", label+" needs a SKU")
+		case skus[v.SKU]:
+			addErr("variants", label+" repeats SKU "+v.SKU)
+		}
+		skus[v.SKU] = true
+
+		if v.Price <= 0 {
+			addErr("variants", label+" needs a price above 0")
+		}
+		if v.Stock < 0 {
+			addErr("variants", label+" cannot have negative stock")
+		}
+
+		if len(v.Options) != len(product.Options) {
+			addErr("variants", label+" must set a value for every option")
+			continue
+		}
+		keys := make([]string, 0, len(v.Options))
+		for name, value := range v.Options {
+			if !allowed[name][value] {
+				addErr("variants", fmt.Sprintf("%s has invalid value %q for option %q", label, value, name))
+			}
+			keys = append(keys, name+"="+value)
+		}
+		sort.Strings(keys)
+		combination := strings.Join(keys, "\x00")
+		if combinations[combination] {
+			addErr("variants", label+" repeats the options of another variant")
+		}
+		combinations[combination] = true
+	}
+
+	if len(fields) > 0 {
+		return fields
+	}
+
+	if len(product.Variants) > 0 {
+		product.Price = product.Variants[0].Price
+		product.Stock = 0
+		for _, v := range product.Variants {
+			product.Price = min(product.Price, v.Price)
+			product.Stock += v.Stock
+		}
+	}
+	return nil
+}
+
+// unchangedProductFilter matches product only while it is as it was read.
+// Checkout moves stock with $inc without touching updatedAt, so the stock
+// of the product and of every variant is compared as well; a write that
+// replaces the variants would otherwise bring back stock sold meanwhile.
+func unchangedProductFilter(product models.Product) bson.M {
+	filter := bson.M{"_id": product.ID, "stock": product.Stock}
+	if product.UpdatedAt.IsZero() {
+		filter["updatedAt"] = bson.M{"$in": bson.A{product.UpdatedAt, nil}}
+	} else {
+		filter["updatedAt"] = product.UpdatedAt
+	}
+	if len(product.Variants) > 0 {
+		variants := make(bson.A, 0, len(product.Variants))
+		for _, v := range product.Variants {
+			variants = append(variants, bson.M{"$elemMatch": bson.M{"_id": v.ID, "stock": v.Stock}})
+		}
+		filter["variants"] = bson.M{"$all": variants, "$size": len(product.Variants)}
+	}
+	return filter
+}
+
+// SetProductVariants replaces the options and variants of a product.
+// Removing all variants requires a new price and stock for the product
+// itself, since the old ones were only aggregates of its variants.
+// Sending empty lists turns it back into a product without variants.
+func SetProductVariants(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var body struct {
+		Options  []models.ProductOption  `json:"options"`
+		Variants []models.ProductVariant `json:"variants"`
+		Price    *float64                `json:"price"`
+		Stock    *int                    `json:"stock"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var previousProduct models.Product
+	if err := database.ProductCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&previousProduct); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	updatedProduct := previousProduct
+	updatedProduct.Options = body.Options
+	updatedProduct.Variants = body.Variants
+	fields := applyVariants(&updatedProduct, previousProduct.Variants)
+	if fields == nil {
+		fields = map[string][]string{}
+	}
+	if len(updatedProduct.Variants) == 0 && len(previousProduct.Variants) > 0 {
+		switch {
+		case body.Price == nil:
+			fields["price"] = append(fields["price"], "Price is required when removing all variants")
+		case *body.Price <= 0:
+			fields["price"] = append(fields["price"], "Price must be above 0")
+		default:
+			updatedProduct.Price = *body.Price
+		}
+		switch {
+		case body.Stock == nil:
+			fields["stock"] = append(fields["stock"], "Stock is required when removing all variants")
+		case *body.Stock < 0:
+			fields["stock"] = append(fields["stock"], "Stock cannot be negative")
+		default:
+			updatedProduct.Stock = *body.Stock
+		}
+	}
+	if len(fields) > 0 {
+		respondFieldErrors(c, fields)
+		return
+	}
+	updatedProduct.UpdatedAt = time.Now()
+
+	update := bson.M{"$set": bson.M{
+		"options":   updatedProduct.Options,
+		"variants":  updatedProduct.Variants,
+		"price":     updatedProduct.Price,
+		"stock":     updatedProduct.Stock,
+		"updatedAt": updatedProduct.UpdatedAt,
+	}}
+	if len(updatedProduct.Variants) == 0 {
+		update = bson.M{
+			"$set":   bson.M{"price": updatedProduct.Price, "stock": updatedProduct.Stock, "updatedAt": updatedProduct.UpdatedAt},
+			"$unset": bson.M{"options": "", "variants": ""},
+		}
+	}
+
+	result, err := database.ProductCollection.UpdateOne(ctx, unchangedProductFilter(previousProduct), update)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "SKU already used by another product"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update variants"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product changed while updating variants, please reload and try again"})
+		return
+	}
+
+	if err := search.Default.Index(ctx, updatedProduct); err != nil {
+		log.Println("⚠️  Failed to index product:", err)
+	}
+
+	recordAudit(ctx, c, models.AuditLog{
+		Action:     models.AuditProductUpdated,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		Changes:    auditDiff(previousProduct, updatedProduct),
+	})
+
+	c.JSON(http.StatusOK, updatedProduct)
+}
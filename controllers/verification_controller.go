@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/mailer"
+	"ecommerce/models"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const verificationResendInterval = time.Minute
+
+func requireVerifiedEmail() bool {
+	return config.GetEnvBool("REQUIRE_VERIFIED_EMAIL_FOR_CHECKOUT", false)
+}
+
+// sendVerificationEmail sends a verification link for email, which is
+// either the user's current address or the pending address they are
+// changing to.
+func sendVerificationEmail(ctx context.Context, user models.User, email string) error {
+	raw, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := config.GetEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	now := time.Now()
+	verification := models.EmailVerification{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+
+	// Only the most recent link for this address is valid. Links for the
+	// other address, current or pending, keep working.
+	if _, err := database.EmailVerificationCollection.DeleteMany(ctx, bson.M{"userId": user.ID, "email": email}); err != nil {
+		return err
+	}
+	if _, err := database.EmailVerificationCollection.InsertOne(ctx, verification); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/verify-email?token=%s", config.GetEnv("APP_BASE_URL", "http://localhost:8080"), url.QueryEscape(raw))
+
+	return mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Hi %s,\n\nPlease verify your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n", user.Name, link, ttl),
+	})
+}
+
+func VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var verification models.EmailVerification
+	err := database.EmailVerificationCollection.FindOneAndDelete(ctx, bson.M{"tokenHash": hashToken(token)}).Decode(&verification)
+	if err != nil || time.Now().After(verification.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	now := time.Now()
+	filter := bson.M{"_id": verification.UserID, "email": verification.Email}
+	update := bson.M{"$set": bson.M{"emailVerified": true, "emailVerifiedAt": now}}
+
+	// A link sent to a pending address completes an email change.
+	var user models.User
+	err = database.UserCollection.FindOne(ctx, bson.M{"_id": verification.UserID}).Decode(&user)
+	if err == nil && user.PendingEmail != "" && user.PendingEmail == verification.Email {
+		taken, err := database.UserCollection.CountDocuments(ctx, bson.M{"email": verification.Email})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+			return
+		}
+		if taken > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+			return
+		}
+
+		filter = bson.M{"_id": verification.UserID, "pendingEmail": verification.Email}
+		update = bson.M{
+			"$set":   bson.M{"email": verification.Email, "emailVerified": true, "emailVerifiedAt": now},
+			"$unset": bson.M{"pendingEmail": ""},
+		}
+	}
+
+	result, err := database.UserCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func ResendVerification(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	// The response is the same whether or not the account exists so the
+	// endpoint cannot be used to discover registered emails.
+	response := gin.H{"message": "If the account exists and is unverified, a verification email has been sent"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := database.UserCollection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&user)
+	if err != nil || user.EmailVerified {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var last models.EmailVerification
+	err = database.EmailVerificationCollection.FindOne(ctx, bson.M{"userId": user.ID, "email": user.Email}).Decode(&last)
+	if err == nil && time.Since(last.CreatedAt) < verificationResendInterval {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Please wait before requesting another verification email"})
+		return
+	}
+
+	if err := sendVerificationEmail(ctx, user, user.Email); err != nil {
+		log.Println("⚠️  Failed to send verification email:", err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexes := map[*mongo.Collection][]mongo.IndexModel{
+		RefreshTokenCollection: {
+			{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "familyId", Value: 1}}},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		RoleAuditCollection: {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		},
+		EmailVerificationCollection: {
+			{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		PasswordResetCollection: {
+			{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		MFAChallengeCollection: {
+			{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		LoginAttemptCollection: {
+			{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "updatedAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(24 * 60 * 60)},
+		},
+		AccountUnlockCollection: {
+			{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		OIDCStateCollection: {
+			{Keys: bson.D{{Key: "stateHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		UserCollection: {
+			{
+				Keys:    bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+				Options: options.Index().SetUnique(true).SetSparse(true),
+			},
+			{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "role", Value: 1}, {Key: "createdAt", Value: -1}}},
+		},
+		RevokedTokenCollection: {
+			{Keys: bson.D{{Key: "jti", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "createdAt", Value: 1}}},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		UserRevocationCollection: {
+			{Keys: bson.D{{Key: "updatedAt", Value: 1}}},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		SessionCollection: {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "lastSeenAt", Value: -1}}},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		APIKeyCollection: {
+			{Keys: bson.D{{Key: "keyHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "prefix", Value: 1}}},
+		},
+		RoleCollection: {
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		ProductCollection: {
+			{Keys: bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+			{Keys: bson.D{{Key: "price", Value: 1}, {Key: "_id", Value: 1}}},
+			{Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+			{Keys: bson.D{{Key: "stock", Value: 1}, {Key: "price", Value: 1}}},
+			{Keys: bson.D{{Key: "categoryIds", Value: 1}}},
+			{Keys: bson.D{{Key: "variants.sku", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+			{
+				Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+				Options: options.Index().SetName("product_text").SetWeights(bson.M{"name": 3, "description": 1}),
+			},
+		},
+		CategoryCollection: {
+			{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "parentId", Value: 1}, {Key: "sortOrder", Value: 1}}},
+			{Keys: bson.D{{Key: "ancestors", Value: 1}}},
+		},
+		CartCollection: {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "productId", Value: 1}, {Key: "variantId", Value: 1}}},
+			{Keys: bson.D{{Key: "guestId", Value: 1}}, Options: options.Index().SetSparse(true)},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		AddressCollection: {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		},
+		DataExportCollection: {
+			{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		AuditLogCollection: {
+			{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "action", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "actorId", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "targetId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		},
+	}
+
+	for coll, models := range indexes {
+		if _, err := coll.Indexes().CreateMany(ctx, models); err != nil {
+			log.Printf("⚠️  Failed to create indexes on %s: %v", coll.Name(), err)
+		}
+	}
+}
@@ -39,10 +39,44 @@ var UserCollection *mongo.Collection
 var ProductCollection *mongo.Collection
 var OrderCollection *mongo.Collection
 var CartCollection *mongo.Collection
+var RefreshTokenCollection *mongo.Collection
+var RoleAuditCollection *mongo.Collection
+var EmailVerificationCollection *mongo.Collection
+var PasswordResetCollection *mongo.Collection
+var MFAChallengeCollection *mongo.Collection
+var LoginAttemptCollection *mongo.Collection
+var AccountUnlockCollection *mongo.Collection
+var OIDCStateCollection *mongo.Collection
+var RevokedTokenCollection *mongo.Collection
+var UserRevocationCollection *mongo.Collection
+var SessionCollection *mongo.Collection
+var APIKeyCollection *mongo.Collection
+var RoleCollection *mongo.Collection
+var AuditLogCollection *mongo.Collection
+var AddressCollection *mongo.Collection
+var DataExportCollection *mongo.Collection
+var CategoryCollection *mongo.Collection
 
 func InitCollections() {
 	UserCollection = DB.Collection("users")
 	ProductCollection = DB.Collection("products")
 	OrderCollection = DB.Collection("orders")
 	CartCollection = DB.Collection("carts")
+	RefreshTokenCollection = DB.Collection("refresh_tokens")
+	RoleAuditCollection = DB.Collection("role_audits")
+	EmailVerificationCollection = DB.Collection("email_verifications")
+	PasswordResetCollection = DB.Collection("password_resets")
+	MFAChallengeCollection = DB.Collection("mfa_challenges")
+	LoginAttemptCollection = DB.Collection("login_attempts")
+	AccountUnlockCollection = DB.Collection("account_unlocks")
+	OIDCStateCollection = DB.Collection("oidc_states")
+	RevokedTokenCollection = DB.Collection("revoked_tokens")
+	UserRevocationCollection = DB.Collection("user_token_revocations")
+	SessionCollection = DB.Collection("sessions")
+	APIKeyCollection = DB.Collection("api_keys")
+	RoleCollection = DB.Collection("roles")
+	AuditLogCollection = DB.Collection("audit_logs")
+	AddressCollection = DB.Collection("addresses")
+	DataExportCollection = DB.Collection("data_exports")
+	CategoryCollection = DB.Collection("categories")
 }
@@ -0,0 +1,127 @@
+// Package imaging validates uploaded images and scales them down into
+// thumbnails using only the standard library decoders.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooManyPixels   = errors.New("image dimensions too large")
+)
+
+// formats lists the sniffed content types with a registered decoder.
+var formats = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Decode checks that data is a JPEG, PNG or GIF by its content, not its
+// name, and that it has at most maxPixels pixels before decoding it, so a
+// small file cannot expand into a huge bitmap. GIFs yield their first
+// frame.
+func Decode(data []byte, maxPixels int) (img image.Image, contentType string, err error) {
+	contentType = http.DetectContentType(data)
+	if !formats[contentType] {
+		return nil, "", ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+
+	img, _, err = image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	return img, contentType, nil
+}
+
+// Thumbnail scales img down so its longer side is maxEdge, averaging the
+// source pixels each target pixel covers. It returns false when img is
+// already that small.
+func Thumbnail(img image.Image, maxEdge int) (*image.RGBA, bool) {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw <= maxEdge && sh <= maxEdge {
+		return nil, false
+	}
+
+	dw, dh := maxEdge, sh*maxEdge/sw
+	if sh > sw {
+		dw, dh = sw*maxEdge/sh, maxEdge
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	// Convert only the band of source rows one target row covers at a
+	// time, rather than copying the whole image, to keep memory per upload
+	// close to the size of the decoded source.
+	band := image.NewRGBA(image.Rect(0, 0, sw, (sh+dh-1)/dh))
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, max((dy+1)*sh/dh, dy*sh/dh+1)
+		draw.Draw(band, image.Rect(0, 0, sw, y1-y0), img, image.Pt(b.Min.X, b.Min.Y+y0), draw.Src)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, max((dx+1)*sw/dw, dx*sw/dw+1)
+
+			var r, g, bl, a, n int
+			for y := y0; y < y1; y++ {
+				row := band.Pix[(y-y0)*band.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					bl += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst, true
+}
+
+// Encode writes a thumbnail in the format of its source: JPEG for JPEG
+// sources and PNG otherwise, which keeps transparency. It returns the
+// content type and file extension used.
+func Encode(img image.Image, sourceType string) (data []byte, contentType, ext string, err error) {
+	var buf bytes.Buffer
+	if sourceType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", "jpg", err
+	}
+	err = png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", "png", err
+}
+
+// Extension returns the file extension for a content type accepted by
+// Decode.
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return "jpg"
+	case "image/gif":
+		return "gif"
+	default:
+		return "png"
+	}
+}
@@ -0,0 +1,62 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		maxEdge       int
+		wantW, wantH  int
+		scaled        bool
+	}{
+		{"landscape", 1000, 500, 200, 200, 100, true},
+		{"portrait", 500, 1000, 200, 100, 200, true},
+		{"square", 640, 640, 320, 320, 320, true},
+		{"uneven ratio", 1001, 333, 100, 100, 33, true},
+		{"thin strip keeps one pixel", 2000, 1, 100, 100, 1, true},
+		{"already small", 150, 100, 200, 0, 0, false},
+		{"exactly max edge", 200, 120, 200, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+			thumb, ok := Thumbnail(src, tt.maxEdge)
+			if ok != tt.scaled {
+				t.Fatalf("Thumbnail scaled = %v, want %v", ok, tt.scaled)
+			}
+			if !ok {
+				return
+			}
+			if b := thumb.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("Thumbnail size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailAveragesPixels(t *testing.T) {
+	// A source not anchored at the origin, split into a black left half
+	// and a white right half.
+	src := image.NewGray(image.Rect(10, 10, 14, 12))
+	for y := 10; y < 12; y++ {
+		for x := 12; x < 14; x++ {
+			src.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+
+	thumb, ok := Thumbnail(src, 2)
+	if !ok {
+		t.Fatal("Thumbnail did not scale")
+	}
+	if got := thumb.RGBAAt(0, 0); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("left pixel = %v, want black", got)
+	}
+	if got := thumb.RGBAAt(1, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("right pixel = %v, want white", got)
+	}
+}
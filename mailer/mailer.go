@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"ecommerce/config"
+	"log"
+	"os"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email such as verification links.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var Default Mailer = NewWriterMailer(os.Stdout)
+
+// Init selects the mailer from MAILER: "smtp", "file" (MAILER_FILE) or
+// "stdout", which is the default for local development.
+func Init() {
+	switch config.GetEnv("MAILER", "stdout") {
+	case "smtp":
+		Default = NewSMTPMailer(
+			config.GetEnv("SMTP_HOST", "localhost"),
+			config.GetEnv("SMTP_PORT", "587"),
+			config.GetEnv("SMTP_USERNAME", ""),
+			config.GetEnv("SMTP_PASSWORD", ""),
+			config.GetEnv("MAIL_FROM", "no-reply@localhost"),
+		)
+	case "file":
+		path := config.GetEnv("MAILER_FILE", "mail.log")
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatal("❌ Failed to open mailer file:", err)
+		}
+		Default = NewWriterMailer(f)
+	default:
+		Default = NewWriterMailer(os.Stdout)
+	}
+}
+
+func Send(ctx context.Context, msg Message) error {
+	return Default.Send(ctx, msg)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"ecommerce/apikey"
+	"ecommerce/database"
+	"ecommerce/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// lastUsedResolution limits how often lastUsedAt is written for busy keys.
+const lastUsedResolution = time.Minute
+
+func authenticateAPIKey(c *gin.Context, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var k models.APIKey
+	err := database.APIKeyCollection.FindOne(ctx, bson.M{
+		"keyHash":   apikey.Hash(key),
+		"revokedAt": bson.M{"$exists": false},
+	}).Decode(&k)
+	if err != nil || (k.ExpiresAt != nil && now.After(*k.ExpiresAt)) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired API key"})
+		return
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > lastUsedResolution {
+		_, _ = database.APIKeyCollection.UpdateOne(ctx, bson.M{"_id": k.ID}, bson.M{"$set": bson.M{"lastUsedAt": now}})
+	}
+
+	setPrincipal(c, Principal{APIKeyID: k.ID, Scopes: k.Scopes})
+	c.Next()
+}
+
+// UserMiddleware rejects API keys on routes that act on behalf of a logged
+// in user.
+func UserMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p, ok := GetPrincipal(c); ok && p.IsAPIKey() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied: API keys cannot access user endpoints"})
+			return
+		}
+		c.Next()
+	}
+}
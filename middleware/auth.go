@@ -1,21 +1,22 @@
 package middleware
 
 import (
-	"context"
-	"ecommerce/database"
+	"ecommerce/apikey"
+	"ecommerce/auth"
+	"ecommerce/revocation"
 	"net/http"
-	"os"
-	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
-
 func AuthMiddleware() gin.HandlerFunc {
     return func(c *gin.Context) {
+        if key := c.GetHeader("X-API-Key"); key != "" {
+            authenticateAPIKey(c, key)
+            return
+        }
+
         tokenString := c.GetHeader("Authorization")
         if tokenString == "" {
             c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token required"})
@@ -26,38 +27,49 @@ func AuthMiddleware() gin.HandlerFunc {
             tokenString = tokenString[7:]
         }
 
-        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-        defer cancel()
-
-        var blacklisted bson.M
-        err := database.DB.Collection("blacklist_tokens").FindOne(ctx, bson.M{"token": tokenString}).Decode(&blacklisted)
-        if err == nil {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been blacklisted"})
+        if apikey.Looks(tokenString) {
+            authenticateAPIKey(c, tokenString)
             return
         }
 
-        token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-            return jwtSecret, nil
-        })
-
-        if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-            c.Set("userId", claims["userId"])
-            c.Set("role", claims["role"])
-            c.Next()
-        } else {
+        claims, err := auth.Parse(tokenString)
+        if err != nil {
+            if err == auth.ErrMalformedClaims {
+                c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Malformed token claims"})
+                return
+            }
             c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+            return
+        }
+
+        if revocation.Default.IsRevoked(claims.Id, claims.SessionID, claims.UserID, claims.IssuedAtTime()) {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+            return
         }
+
+        // Claims.Valid already checked both IDs.
+        userID, _ := primitive.ObjectIDFromHex(claims.UserID)
+        sessionID, _ := primitive.ObjectIDFromHex(claims.SessionID)
+        setPrincipal(c, Principal{
+            UserID:    userID,
+            Role:      claims.Role,
+            SessionID: sessionID,
+            MFA:       claims.MFA,
+        })
+        c.Next()
     }
 }
 
-func AdminMiddleware() gin.HandlerFunc {
+// OptionalAuthMiddleware authenticates requests that carry credentials
+// exactly like AuthMiddleware and lets anonymous requests through, for
+// routes that guests may use too.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	authenticate := AuthMiddleware()
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: admin only"})
-			c.Abort()
+		if c.GetHeader("Authorization") == "" && c.GetHeader("X-API-Key") == "" {
+			c.Next()
 			return
 		}
-		c.Next()
+		authenticate(c)
 	}
 }
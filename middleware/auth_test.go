@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"ecommerce/auth"
+	"ecommerce/revocation"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BenchmarkAuthMiddleware measures the per-request cost of a JWT check:
+// signature, claims and the in-memory revocation lookup.
+func BenchmarkAuthMiddleware(b *testing.B) {
+	gin.SetMode(gin.ReleaseMode)
+
+	key, err := auth.GenerateEd25519Key("bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	keys := auth.Keys
+	auth.Keys = auth.NewKeySet()
+	auth.Keys.Add(key)
+	if err := auth.Keys.SetActive(key.ID); err != nil {
+		b.Fatal(err)
+	}
+
+	ctx := context.Background()
+	store := revocation.NewStore(nil, nil, time.Hour)
+	for i := 0; i < 10_000; i++ {
+		_ = store.RevokeToken(ctx, fmt.Sprintf("jti-%d", i), fmt.Sprintf("user-%d", i), time.Now().Add(time.Hour))
+	}
+	defaultStore := revocation.Default
+	revocation.Default = store
+	b.Cleanup(func() {
+		auth.Keys = keys
+		revocation.Default = defaultStore
+	})
+
+	token, err := auth.Sign(auth.Claims{
+		UserID:    primitive.NewObjectID().Hex(),
+		SessionID: primitive.NewObjectID().Hex(),
+		Role:      "customer",
+	}, time.Hour)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/", AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			b.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"ecommerce/config"
+	"ecommerce/rbac"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission admits users whose role grants permission and API keys
+// whose scopes grant it. When REQUIRE_ADMIN_MFA is set, staff users must
+// also have logged in with MFA.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token required"})
+			return
+		}
+
+		if p.IsAPIKey() {
+			if !rbac.ScopesGrant(p.Scopes, permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied: API key lacks permission " + permission})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if !rbac.Has(p.Role, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied: missing permission " + permission})
+			return
+		}
+
+		if config.GetEnvBool("REQUIRE_ADMIN_MFA", false) && !p.MFA {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied: staff accounts must login with MFA"})
+			return
+		}
+		c.Next()
+	}
+}
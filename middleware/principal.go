@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const principalKey = "principal"
+
+// Principal is who an authenticated request acts as: a user holding an
+// access token or an integration holding an API key.
+type Principal struct {
+	UserID    primitive.ObjectID
+	Role      string
+	SessionID primitive.ObjectID
+	MFA       bool
+
+	APIKeyID primitive.ObjectID
+	Scopes   []string
+}
+
+func (p Principal) IsAPIKey() bool {
+	return !p.APIKeyID.IsZero()
+}
+
+// GetPrincipal returns the principal AuthMiddleware stored for the request.
+// ok is false for anonymous requests.
+func GetPrincipal(c *gin.Context) (p Principal, ok bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return Principal{}, false
+	}
+	p, ok = value.(Principal)
+	return p, ok
+}
+
+func setPrincipal(c *gin.Context, p Principal) {
+	c.Set(principalKey, p)
+}
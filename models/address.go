@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Provinces lists the provinces of Indonesia accepted in addresses.
+var Provinces = []string{
+	"Aceh", "Sumatera Utara", "Sumatera Barat", "Riau", "Kepulauan Riau",
+	"Jambi", "Sumatera Selatan", "Kepulauan Bangka Belitung", "Bengkulu",
+	"Lampung", "DKI Jakarta", "Jawa Barat", "Banten", "Jawa Tengah",
+	"DI Yogyakarta", "Jawa Timur", "Bali", "Nusa Tenggara Barat",
+	"Nusa Tenggara Timur", "Kalimantan Barat", "Kalimantan Tengah",
+	"Kalimantan Selatan", "Kalimantan Timur", "Kalimantan Utara",
+	"Sulawesi Utara", "Gorontalo", "Sulawesi Tengah", "Sulawesi Barat",
+	"Sulawesi Selatan", "Sulawesi Tenggara", "Maluku", "Maluku Utara",
+	"Papua", "Papua Barat", "Papua Barat Daya", "Papua Selatan",
+	"Papua Tengah", "Papua Pegunungan",
+}
+
+// Address is an entry in a user's address book.
+type Address struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID `bson:"userId" json:"userId"`
+	Label             string             `bson:"label" json:"label"`
+	RecipientName     string             `bson:"recipientName" json:"recipientName"`
+	Phone             string             `bson:"phone" json:"phone"`
+	Street            string             `bson:"street" json:"street"`
+	District          string             `bson:"district" json:"district"`
+	City              string             `bson:"city" json:"city"`
+	Province          string             `bson:"province" json:"province"`
+	PostalCode        string             `bson:"postalCode" json:"postalCode"`
+	Notes             string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	IsDefaultShipping bool               `bson:"isDefaultShipping" json:"isDefaultShipping"`
+	IsDefaultBilling  bool               `bson:"isDefaultBilling" json:"isDefaultBilling"`
+	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// OrderAddress is a copy of an address taken at checkout, so later edits
+// to the address book do not change past orders.
+type OrderAddress struct {
+	AddressID     primitive.ObjectID `bson:"addressId" json:"addressId"`
+	RecipientName string             `bson:"recipientName" json:"recipientName"`
+	Phone         string             `bson:"phone" json:"phone"`
+	Street        string             `bson:"street" json:"street"`
+	District      string             `bson:"district" json:"district"`
+	City          string             `bson:"city" json:"city"`
+	Province      string             `bson:"province" json:"province"`
+	PostalCode    string             `bson:"postalCode" json:"postalCode"`
+	Notes         string             `bson:"notes,omitempty" json:"notes,omitempty"`
+}
+
+func (a Address) Snapshot() OrderAddress {
+	return OrderAddress{
+		AddressID:     a.ID,
+		RecipientName: a.RecipientName,
+		Phone:         a.Phone,
+		Street:        a.Street,
+		District:      a.District,
+		City:          a.City,
+		Province:      a.Province,
+		PostalCode:    a.PostalCode,
+		Notes:         a.Notes,
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuditLoginSucceeded  = "auth.login"
+	AuditLoginFailed     = "auth.login_failed"
+	AuditLogout          = "auth.logout"
+	AuditProductUpdated  = "product.update"
+	AuditProductDeleted  = "product.delete"
+	AuditCategoryCreated = "category.create"
+	AuditCategoryUpdated = "category.update"
+	AuditCategoryDeleted = "category.delete"
+	AuditOrderStatusSet  = "order.update_status"
+	AuditOrderCanceled   = "order.cancel"
+	AuditUserSuspended   = "user.suspend"
+	AuditUserUnsuspended = "user.unsuspend"
+)
+
+const (
+	AuditActorUser      = "user"
+	AuditActorAPIKey    = "api_key"
+	AuditActorAnonymous = "anonymous"
+)
+
+const (
+	AuditTargetUser     = "user"
+	AuditTargetProduct  = "product"
+	AuditTargetCategory = "category"
+	AuditTargetOrder    = "order"
+)
+
+// AuditLog is one entry of the security audit log. Entries are only ever
+// inserted; nothing in the API updates or deletes them.
+type AuditLog struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Action     string                 `bson:"action" json:"action"`
+	ActorType  string                 `bson:"actorType" json:"actorType"`
+	ActorID    primitive.ObjectID     `bson:"actorId,omitempty" json:"actorId,omitempty"`
+	TargetType string                 `bson:"targetType,omitempty" json:"targetType,omitempty"`
+	TargetID   primitive.ObjectID     `bson:"targetId,omitempty" json:"targetId,omitempty"`
+	IP         string                 `bson:"ip" json:"ip"`
+	UserAgent  string                 `bson:"userAgent,omitempty" json:"userAgent,omitempty"`
+	Changes    map[string]AuditChange `bson:"changes,omitempty" json:"changes,omitempty"`
+	Metadata   map[string]interface{} `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	CreatedAt  time.Time              `bson:"createdAt" json:"createdAt"`
+}
+
+// AuditChange holds the value of a field before and after the action.
+type AuditChange struct {
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
@@ -6,10 +6,16 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// CartItem belongs to either a user or a guest cart. GuestID is the hash of
+// the anonymous cart token; guest items expire at ExpiresAt. VariantID is
+// set for products sold through variants.
 type CartItem struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	UserID    primitive.ObjectID `bson:"userId,omitempty" json:"userId,omitempty"`
+	GuestID   string             `bson:"guestId,omitempty" json:"-"`
 	ProductID primitive.ObjectID `bson:"productId" json:"productId"`
+	VariantID primitive.ObjectID `bson:"variantId,omitempty" json:"variantId,omitempty"`
 	Quantity  int                `bson:"quantity" json:"quantity"`
 	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
 }
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Category is a node of the category tree. Ancestors lists the IDs from the
+// root down to the parent, so a subtree can be found with one query.
+type Category struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name      string               `bson:"name" json:"name"`
+	Slug      string               `bson:"slug" json:"slug"`
+	ParentID  *primitive.ObjectID  `bson:"parentId,omitempty" json:"parentId"`
+	Ancestors []primitive.ObjectID `bson:"ancestors" json:"ancestors"`
+	SortOrder int                  `bson:"sortOrder" json:"sortOrder"`
+	CreatedAt time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time            `bson:"updatedAt" json:"updatedAt"`
+}
+
+// CategoryNode is a category with its children, used to return the tree.
+type CategoryNode struct {
+	Category `bson:",inline"`
+	Children []*CategoryNode `json:"children"`
+}
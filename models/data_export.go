@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is a user's request for a copy of their personal data. The
+// ZIP archive is stored on the document once generated; the document and
+// archive are removed by a TTL index at ExpiresAt.
+type DataExport struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
+	Status      string             `bson:"status" json:"status"`
+	TokenHash   string             `bson:"tokenHash" json:"-"`
+	Archive     []byte             `bson:"archive,omitempty" json:"-"`
+	Size        int                `bson:"size,omitempty" json:"size,omitempty"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	CompletedAt *time.Time         `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	ExpiresAt   time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
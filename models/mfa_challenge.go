@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MFAChallenge is created when a user with MFA enabled passes the password
+// step of login. The client exchanges it together with a TOTP or recovery
+// code for the actual tokens.
+type MFAChallenge struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	Attempts  int                `bson:"attempts" json:"attempts"`
+	// ThrottleCounted is set when the password step already counted an
+	// attempt against the login throttle, which then covers the first code.
+	ThrottleCounted bool      `bson:"throttleCounted,omitempty" json:"-"`
+	ExpiresAt       time.Time `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt       time.Time `bson:"createdAt" json:"createdAt"`
+}
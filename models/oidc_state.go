@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OIDCState holds the per-login secrets of an authorization code flow
+// between the redirect to the provider and its callback.
+type OIDCState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Provider     string             `bson:"provider" json:"provider"`
+	StateHash    string             `bson:"stateHash" json:"-"`
+	Nonce        string             `bson:"nonce" json:"-"`
+	CodeVerifier string             `bson:"codeVerifier" json:"-"`
+	ExpiresAt    time.Time          `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+}
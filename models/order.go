@@ -9,10 +9,19 @@ type Order struct {
 	Total     float64              `bson:"total" json:"total"`
 	Status    string               `bson:"status" json:"status"`
 	CreatedAt int64                `bson:"createdAt" json:"createdAt"`
+
+	ShippingAddress *OrderAddress `bson:"shippingAddress,omitempty" json:"shippingAddress,omitempty"`
+	BillingAddress  *OrderAddress `bson:"billingAddress,omitempty" json:"billingAddress,omitempty"`
 }
 
+// OrderItem records what was bought at the price paid. For variants the
+// SKU and option values are copied so the order reads the same after the
+// variant changes.
 type OrderItem struct {
 	ProductID primitive.ObjectID `bson:"productId" json:"productId"`
+	VariantID primitive.ObjectID `bson:"variantId,omitempty" json:"variantId,omitempty"`
+	SKU       string             `bson:"sku,omitempty" json:"sku,omitempty"`
+	Options   map[string]string  `bson:"options,omitempty" json:"options,omitempty"`
 	Quantity  int                `bson:"quantity" json:"quantity"`
 	Price     float64            `bson:"price" json:"price"`
 }
@@ -6,12 +6,68 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Product is sold either as is or through its variants. When it has
+// variants, Price is the lowest variant price and Stock the total variant
+// stock, kept in step so listings can sort and filter on them.
 type Product struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name        string             `bson:"name" json:"name" binding:"required"`
-	Description string             `bson:"description" json:"description" binding:"required"`
-	Price       float64            `bson:"price" json:"price" binding:"required"`
-	Stock       int                `bson:"stock" json:"stock" binding:"required"`
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name        string               `bson:"name" json:"name" binding:"required"`
+	Description string               `bson:"description" json:"description" binding:"required"`
+	Price       float64              `bson:"price" json:"price"`
+	Stock       int                  `bson:"stock" json:"stock"`
+	CategoryIDs []primitive.ObjectID `bson:"categoryIds,omitempty" json:"categoryIds"`
+	Options     []ProductOption      `bson:"options,omitempty" json:"options,omitempty"`
+	Variants    []ProductVariant     `bson:"variants,omitempty" json:"variants,omitempty"`
+	Images      []ProductImage       `bson:"images,omitempty" json:"images,omitempty"`
+	CreatedAt   time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time            `bson:"updatedAt" json:"updatedAt"`
+}
+
+// ProductOption is an axis the variants differ on, such as size or colour.
+type ProductOption struct {
+	Name   string   `bson:"name" json:"name"`
+	Values []string `bson:"values" json:"values"`
+}
+
+// ProductVariant is one combination of option values, e.g.
+// {"Size": "M", "Colour": "Red"}, with its own SKU, price and stock.
+type ProductVariant struct {
+	ID      primitive.ObjectID `bson:"_id" json:"id"`
+	SKU     string             `bson:"sku" json:"sku"`
+	Options map[string]string  `bson:"options" json:"options"`
+	Price   float64            `bson:"price" json:"price"`
+	Stock   int                `bson:"stock" json:"stock"`
+}
+
+// Variant returns the variant with the given ID.
+func (p Product) Variant(id primitive.ObjectID) (ProductVariant, bool) {
+	for _, v := range p.Variants {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return ProductVariant{}, false
+}
+
+// ProductImage is an uploaded image, in display order within the product.
+// Keys locate the files in the blob store.
+type ProductImage struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	URL         string             `bson:"url" json:"url"`
+	Key         string             `bson:"key" json:"-"`
+	ContentType string             `bson:"contentType" json:"contentType"`
+	Width       int                `bson:"width" json:"width"`
+	Height      int                `bson:"height" json:"height"`
+	Size        int                `bson:"size" json:"size"`
+	Thumbnails  []ImageThumbnail   `bson:"thumbnails" json:"thumbnails"`
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
-}
\ No newline at end of file
+}
+
+// ImageThumbnail is a scaled down copy whose longer side is MaxEdge.
+type ImageThumbnail struct {
+	MaxEdge int    `bson:"maxEdge" json:"maxEdge"`
+	URL     string `bson:"url" json:"url"`
+	Key     string `bson:"key" json:"-"`
+	Width   int    `bson:"width" json:"width"`
+	Height  int    `bson:"height" json:"height"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is stored server-side so it can be rotated and revoked.
+// Only the SHA-256 hash of the token is persisted. Every token issued by
+// rotating another one shares its FamilyID, which lets us revoke the whole
+// chain when a rotated token is presented again.
+type RefreshToken struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID  `bson:"userId" json:"userId"`
+	FamilyID   primitive.ObjectID  `bson:"familyId" json:"familyId"`
+	TokenHash  string              `bson:"tokenHash" json:"-"`
+	MFA        bool                `bson:"mfa" json:"mfa"`
+	ExpiresAt  time.Time           `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+	RevokedAt  *time.Time          `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+	ReplacedBy *primitive.ObjectID `bson:"replacedBy,omitempty" json:"replacedBy,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PermProductsRead       = "products.read"
+	PermProductsCreate     = "products.create"
+	PermProductsUpdate     = "products.update"
+	PermProductsDelete     = "products.delete"
+	PermCategoriesManage   = "categories.manage"
+	PermOrdersRead         = "orders.read"
+	PermOrdersUpdateStatus = "orders.update_status"
+	PermOrdersCancel       = "orders.cancel"
+	PermUsersRead          = "users.read"
+	PermUsersManageRoles   = "users.manage_roles"
+	PermUsersUnlock        = "users.unlock"
+	PermUsersSuspend       = "users.suspend"
+	PermAPIKeysManage      = "api_keys.manage"
+	PermRolesManage        = "roles.manage"
+	PermAuditRead          = "audit.read"
+
+	// PermAll grants every permission. Only the built-in admin role has it.
+	PermAll = "*"
+)
+
+var Permissions = []string{
+	PermProductsRead,
+	PermProductsCreate,
+	PermProductsUpdate,
+	PermProductsDelete,
+	PermCategoriesManage,
+	PermOrdersRead,
+	PermOrdersUpdateStatus,
+	PermOrdersCancel,
+	PermUsersRead,
+	PermUsersManageRoles,
+	PermUsersUnlock,
+	PermUsersSuspend,
+	PermAPIKeysManage,
+	PermRolesManage,
+	PermAuditRead,
+}
+
+// Role is a named set of permissions. User.Role holds the role name.
+// Built-in roles are created on startup and cannot be changed.
+type Role struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Permissions []string           `bson:"permissions" json:"permissions"`
+	BuiltIn     bool               `bson:"builtIn" json:"builtIn"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RoleActionGrant     = "grant"
+	RoleActionRevoke    = "revoke"
+	RoleActionBootstrap = "bootstrap"
+)
+
+// RoleAudit records every change to a user's role. ActorID is empty for
+// changes made by the bootstrap path rather than by an admin.
+type RoleAudit struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID `bson:"userId" json:"userId"`
+	ActorID      primitive.ObjectID `bson:"actorId,omitempty" json:"actorId,omitempty"`
+	Action       string             `bson:"action" json:"action"`
+	PreviousRole string             `bson:"previousRole" json:"previousRole"`
+	Role         string             `bson:"role" json:"role"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+}
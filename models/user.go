@@ -6,6 +6,11 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	RoleAdmin    = "admin"
+	RoleCustomer = "customer"
+)
+
 type User struct {
 	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
 	Name      string              `bson:"name" json:"name"`
@@ -13,4 +18,34 @@ type User struct {
 	Password  string              `bson:"password" json:"-"`
 	Role      string              `bson:"role" json:"role"`
 	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+
+	EmailVerified   bool       `bson:"emailVerified" json:"emailVerified"`
+	EmailVerifiedAt *time.Time `bson:"emailVerifiedAt,omitempty" json:"emailVerifiedAt,omitempty"`
+	PendingEmail    string     `bson:"pendingEmail,omitempty" json:"pendingEmail,omitempty"`
+
+	MFAEnabled       bool     `bson:"mfaEnabled" json:"mfaEnabled"`
+	MFASecret        string   `bson:"mfaSecret,omitempty" json:"-"`
+	MFAPendingSecret string   `bson:"mfaPendingSecret,omitempty" json:"-"`
+	MFALastStep      int64    `bson:"mfaLastStep,omitempty" json:"-"`
+	MFARecoveryCodes []string `bson:"mfaRecoveryCodes,omitempty" json:"-"`
+
+	Identities []Identity `bson:"identities,omitempty" json:"identities,omitempty"`
+
+	// SuspendedAt is set while staff have suspended the account. Suspended
+	// users cannot login and their existing tokens are revoked.
+	SuspendedAt     *time.Time `bson:"suspendedAt,omitempty" json:"suspendedAt,omitempty"`
+	SuspendedReason string     `bson:"suspendedReason,omitempty" json:"suspendedReason,omitempty"`
+
+	// DeletedAt is set when the account is deleted. The document is kept,
+	// anonymized, so orders still reference a user.
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+}
+
+// Identity links the user to an account at an external OpenID Connect
+// provider.
+type Identity struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"-"`
+	Email    string    `bson:"email" json:"email"`
+	LinkedAt time.Time `bson:"linkedAt" json:"linkedAt"`
 }
@@ -0,0 +1,187 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// clockSkew is how far exp and iat may be off from our clock.
+const clockSkew = time.Minute
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type keySet struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("oidc: unexpected signing method %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	})
+	if err != nil {
+		return Claims{}, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Claims{}, errors.New("oidc: invalid id_token")
+	}
+
+	if iss, _ := claims["iss"].(string); iss != p.Issuer {
+		return Claims{}, fmt.Errorf("oidc: unexpected issuer %q", iss)
+	}
+	if !audienceContains(claims["aud"], p.ClientID) {
+		return Claims{}, errors.New("oidc: id_token not issued for this client")
+	}
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return Claims{}, errors.New("oidc: nonce mismatch")
+	}
+
+	now := time.Now()
+	exp, _ := claims["exp"].(float64)
+	if now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return Claims{}, errors.New("oidc: id_token expired")
+	}
+	if iat, ok := claims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(clockSkew)) {
+		return Claims{}, errors.New("oidc: id_token issued in the future")
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return Claims{}, errors.New("oidc: id_token has no subject")
+	}
+
+	result := Claims{Subject: sub}
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+
+	// Some providers send email_verified as a string.
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = v
+	case string:
+		result.EmailVerified = v == "true"
+	}
+
+	return result, nil
+}
+
+func audienceContains(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if s, _ := a.(string); s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// publicKey returns the signing key for kid, refreshing the key set once if
+// the key is unknown so provider key rotation is picked up.
+func (p *Provider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+
+	if keys != nil {
+		if key := keys.lookup(kid); key != nil {
+			return key, nil
+		}
+		if time.Since(keys.fetchedAt) < time.Minute {
+			return nil, fmt.Errorf("oidc: unknown key %q", kid)
+		}
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key := keys.lookup(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown key %q", kid)
+}
+
+func (ks *keySet) lookup(kid string) *rsa.PublicKey {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k
+		}
+	}
+	return ks.keys[kid]
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (*keySet, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &doc); err != nil {
+		return nil, err
+	}
+
+	ks := &keySet{keys: map[string]*rsa.PublicKey{}, fetchedAt: time.Now()}
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := rsaKeyFromJWK(k)
+		if err != nil {
+			continue
+		}
+		ks.keys[k.Kid] = key
+	}
+
+	p.mu.Lock()
+	p.keys = ks
+	p.mu.Unlock()
+
+	return ks, nil
+}
+
+func rsaKeyFromJWK(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("oidc: invalid RSA exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,58 @@
+package oidc
+
+import (
+	"ecommerce/config"
+	"log"
+	"strings"
+)
+
+var providers = map[string]*Provider{}
+
+// Init registers the providers listed in OIDC_PROVIDERS (comma separated).
+// Each provider NAME is configured through OIDC_<NAME>_ISSUER,
+// OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL
+// and optionally OIDC_<NAME>_SCOPES.
+func Init() {
+	for _, name := range strings.Split(config.GetEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := Config{
+			Name:         name,
+			Issuer:       config.GetEnv(prefix+"ISSUER", ""),
+			ClientID:     config.GetEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: config.GetEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  config.GetEnv(prefix+"REDIRECT_URL", ""),
+		}
+		if scopes := config.GetEnv(prefix+"SCOPES", ""); scopes != "" {
+			cfg.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			log.Printf("⚠️  OIDC provider %s is missing ISSUER, CLIENT_ID or REDIRECT_URL, skipping", name)
+			continue
+		}
+
+		providers[name] = NewProvider(cfg)
+		log.Printf("✅ OIDC provider %s registered", name)
+	}
+}
+
+func Get(name string) (*Provider, error) {
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+func Names() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	return names
+}
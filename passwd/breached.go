@@ -0,0 +1,60 @@
+package passwd
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"ecommerce/config"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Breached reports whether password appears in the breached-password list
+// in BREACHED_PASSWORDS_DIR at least BREACHED_PASSWORD_MIN_COUNT times
+// (default 1). Without a directory screening is disabled.
+//
+// The directory uses the k-anonymity range format of Have I Been Pwned:
+// one file per 5-character SHA-1 prefix, named "<PREFIX>.txt", holding
+// "<SUFFIX>:<COUNT>" lines. Only the file for the password's prefix is
+// read, so the list never has to fit in memory.
+func Breached(password string) (bool, error) {
+	dir := config.GetEnv("BREACHED_PASSWORDS_DIR", "")
+	if dir == "" {
+		return false, nil
+	}
+	return breachedIn(dir, password, config.GetEnvInt("BREACHED_PASSWORD_MIN_COUNT", 1))
+}
+
+func breachedIn(dir, password string, minCount int) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := os.Open(filepath.Join(dir, prefix+".txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, count, _ := strings.Cut(line, ":")
+		if !strings.EqualFold(candidate, suffix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			// Lists without counts only contain breached hashes.
+			n = 1
+		}
+		return n >= minCount, nil
+	}
+	return false, scanner.Err()
+}
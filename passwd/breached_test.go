@@ -0,0 +1,57 @@
+package passwd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8.
+const (
+	passwordPrefix = "5BAA6"
+	passwordSuffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+)
+
+func TestBreachedIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string // contents of 5BAA6.txt, none when empty
+		minCount int
+		want     bool
+	}{
+		{"listed", "0018A45C4D1DEF81644B54AB7F969B88D65:1\n" + passwordSuffix + ":9545824\n", 1, true},
+		{"suffix in lowercase", "1e4c9b93f3f0682250b6cf8331b7ee68fd8:3\n", 1, true},
+		{"windows line endings", "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + passwordSuffix + ":3\r\n", 1, true},
+		{"count below minimum", passwordSuffix + ":2\n", 5, false},
+		{"count at minimum", passwordSuffix + ":5\n", 5, true},
+		{"no count means listed once", passwordSuffix + "\n", 1, true},
+		{"no count below minimum", passwordSuffix + "\n", 2, false},
+		{"not listed", "0018A45C4D1DEF81644B54AB7F969B88D65:1\n", 1, false},
+		{"full hash does not match suffix", passwordPrefix + passwordSuffix + ":1\n", 1, false},
+		{"no file for prefix", "", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.file != "" {
+				if err := os.WriteFile(filepath.Join(dir, passwordPrefix+".txt"), []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := breachedIn(dir, "password", tt.minCount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("breachedIn = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreachedDisabledWithoutDir(t *testing.T) {
+	t.Setenv("BREACHED_PASSWORDS_DIR", "")
+	if got, err := Breached("password"); err != nil || got {
+		t.Errorf("Breached = %v, %v, want false, nil", got, err)
+	}
+}
@@ -0,0 +1,37 @@
+package passwd
+
+import (
+	"ecommerce/config"
+	"log"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultCost = 12
+
+// Cost returns the bcrypt cost from BCRYPT_COST (default 12). Values bcrypt
+// does not accept fall back to the default.
+func Cost() int {
+	cost := config.GetEnvInt("BCRYPT_COST", defaultCost)
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		log.Printf("⚠️  Invalid BCRYPT_COST %d, must be between %d and %d, using %d", cost, bcrypt.MinCost, bcrypt.MaxCost, defaultCost)
+		return defaultCost
+	}
+	return cost
+}
+
+func Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), Cost())
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// NeedsRehash reports whether hash was made with a lower cost than the
+// configured one. Callers rehash after a successful login, when the plain
+// password is at hand.
+func NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < Cost()
+}
@@ -0,0 +1,26 @@
+package passwd
+
+import "testing"
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", 12},
+		{"10", 10},
+		{"14", 14},
+		{"3", 12},
+		{"32", 12},
+		{"-1", 12},
+		{"twelve", 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("BCRYPT_COST", tt.env)
+			if got := Cost(); got != tt.want {
+				t.Errorf("Cost() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
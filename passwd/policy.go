@@ -0,0 +1,125 @@
+// Package passwd hashes passwords and decides whether a new password is
+// acceptable: it must satisfy the configured policy and must not appear in
+// the local breached-password list.
+package passwd
+
+import (
+	"ecommerce/config"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcryptMaxBytes is the longest input bcrypt will hash.
+const bcryptMaxBytes = 72
+
+type Policy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	DisallowPersonal bool
+}
+
+// PolicyFromEnv reads the policy from PASSWORD_MIN_LENGTH (default 8),
+// PASSWORD_MAX_LENGTH (default and upper bound 72 bytes),
+// PASSWORD_REQUIRE_UPPER, PASSWORD_REQUIRE_LOWER, PASSWORD_REQUIRE_DIGIT,
+// PASSWORD_REQUIRE_SYMBOL (default false) and PASSWORD_DISALLOW_PERSONAL
+// (default true).
+func PolicyFromEnv() Policy {
+	p := Policy{
+		MinLength:        config.GetEnvInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:        config.GetEnvInt("PASSWORD_MAX_LENGTH", bcryptMaxBytes),
+		RequireUpper:     config.GetEnvBool("PASSWORD_REQUIRE_UPPER", false),
+		RequireLower:     config.GetEnvBool("PASSWORD_REQUIRE_LOWER", false),
+		RequireDigit:     config.GetEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+		RequireSymbol:    config.GetEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		DisallowPersonal: config.GetEnvBool("PASSWORD_DISALLOW_PERSONAL", true),
+	}
+	if p.MaxLength <= 0 || p.MaxLength > bcryptMaxBytes {
+		p.MaxLength = bcryptMaxBytes
+	}
+	return p
+}
+
+// Validate returns one message per rule the password breaks. email and name
+// belong to the account and are used by DisallowPersonal.
+func (p Policy) Validate(password, email, name string) []string {
+	var problems []string
+
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		problems = append(problems, fmt.Sprintf("Must be at least %d characters long", p.MinLength))
+	}
+	if len(password) > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("Must be at most %d bytes long", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		problems = append(problems, "Must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		problems = append(problems, "Must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "Must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "Must contain a symbol")
+	}
+
+	if p.DisallowPersonal && containsPersonal(password, email, name) {
+		problems = append(problems, "Must not contain your name or email address")
+	}
+
+	return problems
+}
+
+// Check returns every reason the password cannot be used for the account,
+// from the policy and the breached-password list.
+func Check(password, email, name string) ([]string, error) {
+	problems := PolicyFromEnv().Validate(password, email, name)
+
+	breached, err := Breached(password)
+	if err != nil {
+		return problems, err
+	}
+	if breached {
+		problems = append(problems, "Appears in a known data breach, please choose another password")
+	}
+	return problems, nil
+}
+
+// containsPersonal reports whether password contains the local part of the
+// email or any word of the name. Parts shorter than three characters are
+// ignored so short names do not reject most passwords.
+func containsPersonal(password, email, name string) bool {
+	lowered := strings.ToLower(password)
+
+	parts := strings.Fields(strings.ToLower(name))
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok {
+		parts = append(parts, local)
+	}
+
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= 3 && strings.Contains(lowered, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package passwd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	strict := Policy{MinLength: 8, MaxLength: 72, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		want     []string
+	}{
+		{"meets every rule", strict, "Correct-Horse-9", nil},
+		{"too short", Policy{MinLength: 8, MaxLength: 72}, "short", []string{"Must be at least 8 characters long"}},
+		{"length counts characters not bytes", Policy{MinLength: 8, MaxLength: 72}, "ééééééé", []string{"Must be at least 8 characters long"}},
+		{"too long counts bytes", Policy{MinLength: 1, MaxLength: 10}, "éééééé", []string{"Must be at most 10 bytes long"}},
+		{"missing upper", strict, "correct-horse-9", []string{"Must contain an uppercase letter"}},
+		{"missing lower", strict, "CORRECT-HORSE-9", []string{"Must contain a lowercase letter"}},
+		{"missing digit", strict, "Correct-Horse-x", []string{"Must contain a digit"}},
+		{"missing symbol", strict, "CorrectHorse99", []string{"Must contain a symbol"}},
+		{"space counts as symbol", strict, "Correct Horse 9", nil},
+		{"several problems", strict, "abc", []string{
+			"Must be at least 8 characters long",
+			"Must contain an uppercase letter",
+			"Must contain a digit",
+			"Must contain a symbol",
+		}},
+		{"personal info", Policy{MinLength: 8, MaxLength: 72, DisallowPersonal: true}, "jane.doe2024", []string{"Must not contain your name or email address"}},
+		{"personal info allowed", Policy{MinLength: 8, MaxLength: 72}, "jane.doe2024", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.Validate(tt.password, "jane.doe@example.com", "Jane Doe")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsPersonal(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		userName string
+		want     bool
+	}{
+		{"email local part", "xxjsmith99", "jsmith@example.com", "", true},
+		{"email local part any case", "JSmith-2024", "jsmith@example.com", "", true},
+		{"email domain is ignored", "example-password", "jsmith@example.com", "", false},
+		{"first name", "maria1234!", "m@example.com", "Maria Lopez", true},
+		{"last name any case", "ilove-LOPEZ", "m@example.com", "Maria Lopez", true},
+		{"short name parts ignored", "bo-and-li-2024", "x@example.com", "Bo Li", false},
+		{"short local part ignored", "abxyz12345", "ab@example.com", "", false},
+		{"email without @", "johnny-b-goode", "johnny", "", false},
+		{"unrelated", "correct horse battery", "jsmith@example.com", "John Smith", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsPersonal(tt.password, tt.email, tt.userName); got != tt.want {
+				t.Errorf("containsPersonal(%q, %q, %q) = %v, want %v", tt.password, tt.email, tt.userName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFromEnvCapsMaxLength(t *testing.T) {
+	t.Setenv("PASSWORD_MAX_LENGTH", "200")
+	if got := PolicyFromEnv().MaxLength; got != bcryptMaxBytes {
+		t.Errorf("MaxLength = %d, want %d", got, bcryptMaxBytes)
+	}
+}
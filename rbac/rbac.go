@@ -0,0 +1,167 @@
+// Package rbac resolves role names to permissions. Roles live in Mongo and
+// are cached in memory so permission checks do not hit the database.
+package rbac
+
+import (
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/models"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var builtInRoles = []models.Role{
+	{Name: models.RoleAdmin, Description: "Full access", Permissions: []string{models.PermAll}, BuiltIn: true},
+	{Name: models.RoleCustomer, Description: "Shopper without staff access", Permissions: []string{}, BuiltIn: true},
+}
+
+// scopePermissions maps API key scopes onto the permissions they grant.
+var scopePermissions = map[string][]string{
+	models.ScopeProductsRead:  {models.PermProductsRead},
+	models.ScopeProductsWrite: {models.PermProductsRead, models.PermProductsCreate, models.PermProductsUpdate, models.PermProductsDelete, models.PermCategoriesManage},
+	models.ScopeOrdersRead:    {models.PermOrdersRead},
+	models.ScopeOrdersWrite:   {models.PermOrdersRead, models.PermOrdersUpdateStatus, models.PermOrdersCancel},
+}
+
+var (
+	mu    sync.RWMutex
+	roles = map[string]map[string]bool{}
+)
+
+// Init creates the built-in roles, loads every role and reloads them every
+// ROLE_CACHE_REFRESH_INTERVAL (default 30s) to pick up changes made on
+// other replicas.
+func Init() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	for _, r := range builtInRoles {
+		_, err := database.RoleCollection.UpdateOne(ctx,
+			bson.M{"name": r.Name},
+			bson.M{
+				"$set":         bson.M{"permissions": r.Permissions, "description": r.Description, "builtIn": true, "updatedAt": now},
+				"$setOnInsert": bson.M{"createdAt": now},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			log.Println("⚠️  Failed to create built-in role", r.Name+":", err)
+		}
+	}
+
+	if err := Reload(ctx); err != nil {
+		log.Println("⚠️  Failed to load roles:", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(config.GetEnvDuration("ROLE_CACHE_REFRESH_INTERVAL", 30*time.Second))
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := Reload(ctx); err != nil {
+				log.Println("⚠️  Failed to reload roles:", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// Reload replaces the cache with the roles currently stored in Mongo.
+func Reload(ctx context.Context) error {
+	cursor, err := database.RoleCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	var list []models.Role
+	if err := cursor.All(ctx, &list); err != nil {
+		return err
+	}
+
+	next := make(map[string]map[string]bool, len(list))
+	for _, r := range list {
+		perms := make(map[string]bool, len(r.Permissions))
+		for _, p := range r.Permissions {
+			perms[p] = true
+		}
+		next[r.Name] = perms
+	}
+
+	mu.Lock()
+	roles = next
+	mu.Unlock()
+	return nil
+}
+
+func Exists(role string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := roles[role]
+	return ok
+}
+
+// Has reports whether role grants permission.
+func Has(role, permission string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	perms := roles[role]
+	return perms[models.PermAll] || perms[permission]
+}
+
+// IsStaff reports whether role grants any permission at all.
+func IsStaff(role string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(roles[role]) > 0
+}
+
+// Covers reports whether every permission of role other is also granted
+// to role, so a user holding role may hand out other without escalating.
+func Covers(role, other string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	perms := roles[role]
+	if perms[models.PermAll] {
+		return true
+	}
+	for p := range roles[other] {
+		if !perms[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopesGrant reports whether any of the API key scopes grants permission.
+func ScopesGrant(scopes []string, permission string) bool {
+	for _, s := range scopes {
+		for _, p := range scopePermissions[s] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ScopePermissions returns the permissions an API key scope grants.
+func ScopePermissions(scope string) []string {
+	return scopePermissions[scope]
+}
+
+func ValidPermission(permission string) bool {
+	for _, p := range models.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+// Package revocation keeps track of access tokens that were revoked before
+// they expired. Lookups are served from memory; Mongo is the shared source
+// of truth that every replica polls for changes.
+package revocation
+
+import (
+	"context"
+	"ecommerce/auth"
+	"ecommerce/config"
+	"ecommerce/database"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// syncOverlap re-reads a little history on every refresh so entries written
+// by replicas with a slightly skewed clock are not missed.
+const syncOverlap = 5 * time.Second
+
+const sessionKeyPrefix = "session:"
+
+type Store struct {
+	tokenColl *mongo.Collection
+	userColl  *mongo.Collection
+
+	// userTTL is how long a per-user revocation has to be kept: once every
+	// token issued before it has expired it no longer matters.
+	userTTL time.Duration
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	users    map[string]time.Time
+	lastSync time.Time
+}
+
+var Default = NewStore(nil, nil, time.Hour)
+
+// NewStore creates a store backed by the given collections. With nil
+// collections revocations are only kept in memory, which is useful for
+// benchmarks and local experiments.
+func NewStore(tokenColl, userColl *mongo.Collection, userTTL time.Duration) *Store {
+	return &Store{
+		tokenColl: tokenColl,
+		userColl:  userColl,
+		userTTL:   userTTL,
+		tokens:    map[string]time.Time{},
+		users:     map[string]time.Time{},
+	}
+}
+
+// Init loads the current revocations and keeps them in sync every
+// REVOCATION_REFRESH_INTERVAL (default 5s).
+func Init() {
+	Default = NewStore(database.RevokedTokenCollection, database.UserRevocationCollection, auth.AccessTokenTTL())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := Default.Refresh(ctx); err != nil {
+		log.Println("⚠️  Failed to load token revocations:", err)
+	}
+
+	go Default.Run(context.Background(), config.GetEnvDuration("REVOCATION_REFRESH_INTERVAL", 5*time.Second))
+}
+
+// RevokeToken revokes a single token by its jti until it expires.
+func (s *Store) RevokeToken(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	if s.tokenColl != nil {
+		_, err := s.tokenColl.UpdateOne(ctx,
+			bson.M{"jti": jti},
+			bson.M{"$setOnInsert": bson.M{
+				"jti":       jti,
+				"userId":    userID,
+				"expiresAt": expiresAt,
+				"createdAt": time.Now(),
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.tokens[jti] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeSession revokes every access token carrying the given sid claim.
+// Sessions share the token map with a prefix that cannot clash with a jti.
+func (s *Store) RevokeSession(ctx context.Context, sessionID, userID string, expiresAt time.Time) error {
+	return s.RevokeToken(ctx, sessionKeyPrefix+sessionID, userID, expiresAt)
+}
+
+// RevokeUser revokes every token of the user issued before the given time.
+// Token iat claims only have whole seconds, so the cutoff is truncated to
+// the second; otherwise a token issued right after the revocation, in the
+// same second, would be rejected too.
+func (s *Store) RevokeUser(ctx context.Context, userID string, before time.Time) error {
+	before = before.Truncate(time.Second)
+	if s.userColl != nil {
+		_, err := s.userColl.UpdateOne(ctx,
+			bson.M{"_id": userID, "revokedBefore": bson.M{"$not": bson.M{"$gte": before}}},
+			bson.M{"$set": bson.M{
+				"revokedBefore": before,
+				"expiresAt":     before.Add(s.userTTL),
+				"updatedAt":     time.Now(),
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	if before.After(s.users[userID]) {
+		s.users[userID] = before
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// IsRevoked reports whether a token with the given jti, session, subject
+// and issue time has been revoked. It never touches the database.
+func (s *Store) IsRevoked(jti, sessionID, userID string, issuedAt time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.tokens[jti]; ok {
+		return true
+	}
+	if sessionID != "" {
+		if _, ok := s.tokens[sessionKeyPrefix+sessionID]; ok {
+			return true
+		}
+	}
+	if before, ok := s.users[userID]; ok && issuedAt.Before(before) {
+		return true
+	}
+	return false
+}
+
+// Refresh pulls revocations written since the last refresh, including
+// those made by other replicas, and drops expired entries.
+func (s *Store) Refresh(ctx context.Context) error {
+	now := time.Now()
+
+	s.mu.RLock()
+	since := s.lastSync
+	s.mu.RUnlock()
+	if !since.IsZero() {
+		since = since.Add(-syncOverlap)
+	}
+
+	type tokenEntry struct {
+		JTI       string    `bson:"jti"`
+		ExpiresAt time.Time `bson:"expiresAt"`
+	}
+	type userEntry struct {
+		UserID        string    `bson:"_id"`
+		RevokedBefore time.Time `bson:"revokedBefore"`
+	}
+
+	var tokens []tokenEntry
+	var users []userEntry
+
+	if s.tokenColl != nil {
+		cursor, err := s.tokenColl.Find(ctx, bson.M{"createdAt": bson.M{"$gte": since}, "expiresAt": bson.M{"$gt": now}})
+		if err != nil {
+			return err
+		}
+		if err := cursor.All(ctx, &tokens); err != nil {
+			return err
+		}
+	}
+	if s.userColl != nil {
+		cursor, err := s.userColl.Find(ctx, bson.M{"updatedAt": bson.M{"$gte": since}, "expiresAt": bson.M{"$gt": now}})
+		if err != nil {
+			return err
+		}
+		if err := cursor.All(ctx, &users); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range tokens {
+		s.tokens[t.JTI] = t.ExpiresAt
+	}
+	for _, u := range users {
+		if u.RevokedBefore.After(s.users[u.UserID]) {
+			s.users[u.UserID] = u.RevokedBefore
+		}
+	}
+
+	for jti, exp := range s.tokens {
+		if now.After(exp) {
+			delete(s.tokens, jti)
+		}
+	}
+	for userID, before := range s.users {
+		if now.After(before.Add(s.userTTL)) {
+			delete(s.users, userID)
+		}
+	}
+
+	s.lastSync = now
+	return nil
+}
+
+// Run refreshes the store every interval until ctx is done.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			if err := s.Refresh(refreshCtx); err != nil {
+				log.Println("⚠️  Failed to refresh token revocations:", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Len returns the number of cached token and user revocations.
+func (s *Store) Len() (tokens, users int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tokens), len(s.users)
+}
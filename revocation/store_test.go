@@ -0,0 +1,98 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRevokeUserSameSecond(t *testing.T) {
+	s := NewStore(nil, nil, time.Hour)
+	revokedAt := time.Date(2026, 1, 2, 3, 4, 5, 700_000_000, time.UTC)
+	if err := s.RevokeUser(context.Background(), "u1", revokedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"earlier second", revokedAt.Add(-time.Second).Truncate(time.Second), true},
+		{"same second", revokedAt.Truncate(time.Second), false},
+		{"later second", revokedAt.Add(time.Second).Truncate(time.Second), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.IsRevoked("jti", "", "u1", tt.issuedAt); got != tt.revoked {
+				t.Errorf("IsRevoked = %v, want %v", got, tt.revoked)
+			}
+		})
+	}
+
+	if s.IsRevoked("jti", "", "u2", revokedAt.Add(-time.Hour)) {
+		t.Error("revocation leaked to another user")
+	}
+}
+
+func TestRevokeTokenAndSession(t *testing.T) {
+	s := NewStore(nil, nil, time.Hour)
+	ctx := context.Background()
+	exp := time.Now().Add(time.Hour)
+	if err := s.RevokeToken(ctx, "jti-1", "u1", exp); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeSession(ctx, "sid-1", "u1", exp); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if !s.IsRevoked("jti-1", "", "u1", now) {
+		t.Error("revoked jti accepted")
+	}
+	if !s.IsRevoked("jti-2", "sid-1", "u1", now) {
+		t.Error("token of revoked session accepted")
+	}
+	if s.IsRevoked("jti-2", "sid-2", "u1", now) {
+		t.Error("unrelated token rejected")
+	}
+}
+
+// benchStore returns a store holding n revoked tokens and n revoked users.
+func benchStore(b *testing.B, n int) *Store {
+	b.Helper()
+	ctx := context.Background()
+	s := NewStore(nil, nil, time.Hour)
+	for i := 0; i < n; i++ {
+		_ = s.RevokeToken(ctx, fmt.Sprintf("jti-%d", i), fmt.Sprintf("user-%d", i), time.Now().Add(time.Hour))
+		_ = s.RevokeUser(ctx, fmt.Sprintf("revoked-user-%d", i), time.Now())
+	}
+	return s
+}
+
+func BenchmarkIsRevoked(b *testing.B) {
+	for _, n := range []int{0, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			s := benchStore(b, n)
+			issuedAt := time.Now()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if s.IsRevoked("jti", "session", "user", issuedAt) {
+					b.Fatal("token unexpectedly revoked")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIsRevokedParallel(b *testing.B) {
+	s := benchStore(b, 10_000)
+	issuedAt := time.Now()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.IsRevoked("jti", "session", "user", issuedAt)
+		}
+	})
+}
@@ -1,39 +1,124 @@
 package routes
 
 import (
+	"ecommerce/blob"
 	"ecommerce/controllers"
 	"ecommerce/middleware"
+	"ecommerce/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterRoutes(r *gin.Engine) {
+	r.GET("/.well-known/jwks.json", controllers.GetJWKS)
+
+	// Uploaded files are served by the app when stored on local disk.
+	if local, ok := blob.Default.(*blob.LocalStore); ok {
+		r.Static("/uploads", local.Dir)
+	}
 
 	api := r.Group("/api")
 	{
 		api.POST("/register", controllers.Register)
 		api.POST("/login", controllers.Login)
+		api.POST("/login/mfa", controllers.LoginMFA)
+		api.GET("/auth/oidc", controllers.GetOIDCProviders)
+		api.GET("/auth/oidc/:provider/login", controllers.OIDCLogin)
+		api.GET("/auth/oidc/:provider/callback", controllers.OIDCCallback)
+		api.POST("/refresh", controllers.RefreshToken)
+		api.GET("/verify-email", controllers.VerifyEmail)
+		api.POST("/verify-email/resend", controllers.ResendVerification)
+		api.POST("/forgot-password", controllers.ForgotPassword)
+		api.POST("/reset-password", controllers.ResetPassword)
+		api.GET("/unlock-account", controllers.UnlockAccount)
 		api.POST("/logout", controllers.Logout)
+		api.GET("/data-exports/download", controllers.DownloadDataExport)
+		api.GET("/products/search", controllers.SearchProducts)
+		api.GET("/products/autocomplete", controllers.AutocompleteProducts)
+		api.GET("/categories", controllers.GetCategories)
+		api.GET("/categories/:slug/products", controllers.GetCategoryProducts)
+
+		// The cart works for guests too, identified by the X-Cart-Token
+		// header, and for logged in users.
+		cart := api.Group("/cart")
+		cart.Use(middleware.OptionalAuthMiddleware(), middleware.UserMiddleware())
+		{
+			cart.POST("", controllers.AddToCart)
+			cart.GET("", controllers.GetCart)
+			cart.PUT("/:productId", controllers.UpdateCart)
+			cart.DELETE("/:productId", controllers.RemoveFromCart)
+		}
 
 		protected := api.Group("/")
 		protected.Use(middleware.AuthMiddleware())
 		{
+			// Staff routes are guarded per permission. API keys reach the
+			// routes their scopes map to.
 			admin := protected.Group("/admin")
-			admin.Use(middleware.AdminMiddleware())
 			{
-				admin.POST("/products", controllers.CreateProduct)
-				admin.PUT("/products/:id", controllers.UpdateProduct)
-				admin.DELETE("/products/:id", controllers.DeleteProduct)
-				admin.GET("/products", controllers.GetProductsAdmin)
-
-				admin.GET("/orders", controllers.GetOrdersAdmin)
-				admin.GET("/orders/:id", controllers.GetOrderByIDAdmin)
-				admin.PUT("/orders/:id/status", controllers.UpdateOrderStatus)
-				admin.PUT("/orders/:id/cancel", controllers.CancelOrderAdmin)
+				admin.POST("/products", middleware.RequirePermission(models.PermProductsCreate), controllers.CreateProduct)
+				admin.PUT("/products/:id", middleware.RequirePermission(models.PermProductsUpdate), controllers.UpdateProduct)
+				admin.DELETE("/products/:id", middleware.RequirePermission(models.PermProductsDelete), controllers.DeleteProduct)
+				admin.GET("/products", middleware.RequirePermission(models.PermProductsRead), controllers.GetProductsAdmin)
+				admin.PUT("/products/:id/categories", middleware.RequirePermission(models.PermProductsUpdate), controllers.SetProductCategories)
+				admin.PUT("/products/:id/variants", middleware.RequirePermission(models.PermProductsUpdate), controllers.SetProductVariants)
+				admin.POST("/products/:id/images", middleware.RequirePermission(models.PermProductsUpdate), controllers.UploadProductImage)
+				admin.PUT("/products/:id/images/order", middleware.RequirePermission(models.PermProductsUpdate), controllers.ReorderProductImages)
+				admin.DELETE("/products/:id/images/:imageId", middleware.RequirePermission(models.PermProductsUpdate), controllers.DeleteProductImage)
+
+				admin.GET("/categories", middleware.RequirePermission(models.PermProductsRead), controllers.GetCategories)
+				admin.POST("/categories", middleware.RequirePermission(models.PermCategoriesManage), controllers.CreateCategory)
+				admin.PUT("/categories/:id", middleware.RequirePermission(models.PermCategoriesManage), controllers.UpdateCategory)
+				admin.DELETE("/categories/:id", middleware.RequirePermission(models.PermCategoriesManage), controllers.DeleteCategory)
+
+				admin.GET("/orders", middleware.RequirePermission(models.PermOrdersRead), controllers.GetOrdersAdmin)
+				admin.GET("/orders/:id", middleware.RequirePermission(models.PermOrdersRead), controllers.GetOrderByIDAdmin)
+				admin.PUT("/orders/:id/status", middleware.RequirePermission(models.PermOrdersUpdateStatus), controllers.UpdateOrderStatus)
+				admin.PUT("/orders/:id/cancel", middleware.RequirePermission(models.PermOrdersCancel), controllers.CancelOrderAdmin)
+
+				admin.POST("/api-keys", middleware.RequirePermission(models.PermAPIKeysManage), controllers.CreateAPIKey)
+				admin.GET("/api-keys", middleware.RequirePermission(models.PermAPIKeysManage), controllers.GetAPIKeys)
+				admin.DELETE("/api-keys/:id", middleware.RequirePermission(models.PermAPIKeysManage), controllers.RevokeAPIKey)
+
+				admin.GET("/permissions", middleware.RequirePermission(models.PermRolesManage), controllers.GetPermissions)
+				admin.GET("/roles", middleware.RequirePermission(models.PermRolesManage), controllers.GetRoles)
+				admin.POST("/roles", middleware.RequirePermission(models.PermRolesManage), controllers.CreateRole)
+				admin.PUT("/roles/:id", middleware.RequirePermission(models.PermRolesManage), controllers.UpdateRole)
+				admin.DELETE("/roles/:id", middleware.RequirePermission(models.PermRolesManage), controllers.DeleteRole)
+
+				admin.GET("/audit-logs", middleware.RequirePermission(models.PermAuditRead), controllers.GetAuditLogs)
+				admin.GET("/audit-logs/export", middleware.RequirePermission(models.PermAuditRead), controllers.ExportAuditLogs)
+
+				admin.GET("/users", middleware.RequirePermission(models.PermUsersRead), controllers.GetUsers)
+				admin.GET("/users/:id", middleware.RequirePermission(models.PermUsersRead), controllers.GetUserByID)
+				admin.POST("/users/:id/suspend", middleware.RequirePermission(models.PermUsersSuspend), controllers.SuspendUser)
+				admin.POST("/users/:id/unsuspend", middleware.RequirePermission(models.PermUsersSuspend), controllers.UnsuspendUser)
+				admin.PUT("/users/:id/role", middleware.RequirePermission(models.PermUsersManageRoles), controllers.GrantRole)
+				admin.DELETE("/users/:id/role", middleware.RequirePermission(models.PermUsersManageRoles), controllers.RevokeRole)
+				admin.GET("/users/:id/role-audits", middleware.RequirePermission(models.PermUsersRead), controllers.GetRoleAudits)
+				admin.POST("/users/:id/unlock", middleware.RequirePermission(models.PermUsersUnlock), controllers.UnlockUser)
 			}
 
 			user := protected.Group("/user")
+			user.Use(middleware.UserMiddleware())
 			{
+				user.GET("/me", controllers.GetProfile)
+				user.PUT("/me", controllers.UpdateProfile)
+				user.DELETE("/me", controllers.DeleteAccount)
+				user.PUT("/password", controllers.ChangePassword)
+
+				user.POST("/data-exports", controllers.RequestDataExport)
+				user.GET("/data-exports", controllers.GetDataExports)
+
+				user.GET("/sessions", controllers.GetSessions)
+				user.DELETE("/sessions/:id", controllers.DeleteSession)
+				user.DELETE("/sessions", controllers.LogoutEverywhere)
+
+				user.POST("/mfa/enroll", controllers.EnrollMFA)
+				user.POST("/mfa/confirm", controllers.ConfirmMFA)
+				user.POST("/mfa/disable", controllers.DisableMFA)
+				user.POST("/mfa/recovery-codes", controllers.RegenerateRecoveryCodes)
+
 				user.GET("/products", controllers.GetProductsPublic)
 
 				user.POST("/cart", controllers.AddToCart)
@@ -41,6 +126,11 @@ func RegisterRoutes(r *gin.Engine) {
 				user.PUT("/cart/:productId", controllers.UpdateCart)
 				user.DELETE("/cart/:productId", controllers.RemoveFromCart)
 
+				user.GET("/addresses", controllers.GetAddresses)
+				user.POST("/addresses", controllers.CreateAddress)
+				user.PUT("/addresses/:id", controllers.UpdateAddress)
+				user.DELETE("/addresses/:id", controllers.DeleteAddress)
+
 				user.POST("/checkout", controllers.Checkout)
 				user.GET("/orders", controllers.GetOrders)
 				user.PUT("/orders/:id/cancel", controllers.CancelOrder)
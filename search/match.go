@@ -0,0 +1,245 @@
+package search
+
+import (
+	"ecommerce/models"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	nameWeight        = 3.0
+	descriptionWeight = 1.0
+)
+
+// tokenize lowercases s and splits it into words.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// maxEdits is how many typos a query term tolerates. Short terms must match
+// exactly, otherwise almost everything would.
+func maxEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matchWeight scores how well word matches term: 1 for an exact match, less
+// for a prefix match (only when prefix is set, for the word being typed)
+// or a match within maxEdits typos, and 0 otherwise.
+func matchWeight(word, term string, prefix bool) float64 {
+	if word == term {
+		return 1
+	}
+	if prefix && len(term) >= 2 && strings.HasPrefix(word, term) {
+		return 0.8
+	}
+	max := maxEdits(term)
+	if max == 0 {
+		return 0
+	}
+	if d := editDistance([]rune(word), []rune(term), max); d <= max {
+		return 1 - 0.3*float64(d)
+	}
+	return 0
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and swaps of adjacent letters turning a into b, or max+1 once it is known
+// to exceed max.
+func editDistance(a, b []rune, max int) int {
+	if diff := len(a) - len(b); diff > max || -diff > max {
+		return max + 1
+	}
+	prevPrev := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prevPrev[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prevPrev, prev, cur = prev, cur, prevPrev
+	}
+	return prev[len(b)]
+}
+
+// bestMatch returns the highest matchWeight of term against words.
+func bestMatch(words []string, term string, prefix bool) float64 {
+	best := 0.0
+	for _, w := range words {
+		if s := matchWeight(w, term, prefix); s > best {
+			best = s
+			if best == 1 {
+				break
+			}
+		}
+	}
+	return best
+}
+
+// score ranks p against the query terms. Matches in the name count more
+// than matches in the description, and the last term may match as a
+// prefix since it is usually still being typed.
+func score(p models.Product, terms []string) float64 {
+	name := tokenize(p.Name)
+	description := tokenize(p.Description)
+
+	total := 0.0
+	for i, t := range terms {
+		prefix := i == len(terms)-1
+		total += nameWeight*bestMatch(name, t, prefix) + descriptionWeight*bestMatch(description, t, prefix)
+	}
+	return total
+}
+
+// highlight escapes text and wraps the words that match any of the terms
+// in <em>. It returns false when nothing matched.
+func highlight(text string, terms []string) (string, bool) {
+	var b strings.Builder
+	matched := false
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		word := string(runes[i:j])
+		lower := strings.ToLower(word)
+		hit := false
+		for k, t := range terms {
+			if matchWeight(lower, t, k == len(terms)-1) > 0 {
+				hit = true
+				break
+			}
+		}
+		if hit {
+			matched = true
+			b.WriteString("<em>" + html.EscapeString(word) + "</em>")
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		i = j
+	}
+	return b.String(), matched
+}
+
+func highlights(p models.Product, terms []string) map[string]string {
+	h := map[string]string{}
+	if s, ok := highlight(p.Name, terms); ok {
+		h["name"] = s
+	}
+	if s, ok := highlight(p.Description, terms); ok {
+		h["description"] = s
+	}
+	return h
+}
+
+// rankSuggestions keeps the products whose name matches prefix, putting
+// names that start with it first, then shorter names.
+func rankSuggestions(products []models.Product, prefix string, limit int) []Suggestion {
+	terms := tokenize(prefix)
+	if len(terms) == 0 {
+		return []Suggestion{}
+	}
+	lowerPrefix := strings.ToLower(strings.TrimSpace(prefix))
+
+	type candidate struct {
+		product models.Product
+		starts  bool
+	}
+	var candidates []candidate
+	for _, p := range products {
+		words := tokenize(p.Name)
+		ok := true
+		for i, t := range terms {
+			found := false
+			for _, w := range words {
+				if w == t || (i == len(terms)-1 && strings.HasPrefix(w, t)) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			candidates = append(candidates, candidate{p, strings.HasPrefix(strings.ToLower(p.Name), lowerPrefix)})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.starts != b.starts {
+			return a.starts
+		}
+		if len(a.product.Name) != len(b.product.Name) {
+			return len(a.product.Name) < len(b.product.Name)
+		}
+		return a.product.Name < b.product.Name
+	})
+
+	suggestions := []Suggestion{}
+	for _, c := range candidates {
+		if len(suggestions) == limit {
+			break
+		}
+		suggestions = append(suggestions, Suggestion{ID: c.product.ID, Name: c.product.Name})
+	}
+	return suggestions
+}
+
+// rank scores products against the query terms and returns the best limit
+// matches.
+func rank(products []models.Product, terms []string, limit int) []Result {
+	results := []Result{}
+	for _, p := range products {
+		if s := score(p, terms); s > 0 {
+			results = append(results, Result{Product: p, Score: s})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Product.Name < results[j].Product.Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	for i := range results {
+		results[i].Highlights = highlights(results[i].Product, terms)
+	}
+	return results
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"shoe", "shoe", 2, 0},
+		{"shoe", "shoo", 2, 1},
+		{"shoe", "sheo", 2, 1},
+		{"sneakers", "snaekers", 2, 1},
+		{"shoe", "shoes", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 2, 3},
+		{"a", "abcd", 1, 2},
+		{"", "abc", 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := editDistance([]rune(tt.a), []rune(tt.b), tt.max); got != tt.want {
+				t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchWeight(t *testing.T) {
+	tests := []struct {
+		name   string
+		word   string
+		term   string
+		prefix bool
+		want   float64
+	}{
+		{"exact", "shoe", "shoe", false, 1},
+		{"prefix", "shoelace", "sho", true, 0.8},
+		{"prefix not allowed", "shoelace", "sho", false, 0},
+		{"prefix too short", "shoelace", "s", true, 0},
+		{"short term needs exact match", "cot", "cat", false, 0},
+		{"four letters allow one typo", "shoo", "shoe", false, 0.7},
+		{"four letters allow one swap", "sheo", "shoe", false, 0.7},
+		{"four letters reject two typos", "shaa", "shoe", false, 0},
+		{"seven letters reject two typos", "blnkte", "blanket", false, 0},
+		{"eight letters allow two typos", "sneeker", "sneakers", false, 0.4},
+		{"eight letters allow a swap", "snaekers", "sneakers", false, 0.7},
+		{"eight letters reject three typos", "snikr", "sneakers", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchWeight(tt.word, tt.term, tt.prefix); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("matchWeight(%q, %q, %v) = %v, want %v", tt.word, tt.term, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		terms   []string
+		want    string
+		matched bool
+	}{
+		{"match", "Red Shoe", []string{"shoe"}, "Red <em>Shoe</em>", true},
+		{"no match", "Red Hat", []string{"shoe"}, "Red Hat", false},
+		{"escapes markup", "<b>Shoe</b> & co", []string{"shoe"}, "&lt;b&gt;<em>Shoe</em>&lt;/b&gt; &amp; co", true},
+		{"escapes unmatched text", `<script>alert("x")</script>`, []string{"shoe"}, "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;", false},
+		{"typo", "Leather Shoes", []string{"lether"}, "<em>Leather</em> Shoes", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, matched := highlight(tt.text, tt.terms)
+			if got != tt.want || matched != tt.matched {
+				t.Errorf("highlight(%q) = %q, %v, want %q, %v", tt.text, got, matched, tt.want, tt.matched)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"context"
+	"ecommerce/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Memory keeps every product in process and scans them on each query. It
+// suits tests and small catalogs; products changed outside the admin
+// handlers are only picked up on restart.
+type Memory struct {
+	mu       sync.RWMutex
+	products map[primitive.ObjectID]models.Product
+}
+
+func NewMemory() *Memory {
+	return &Memory{products: map[primitive.ObjectID]models.Product{}}
+}
+
+func (m *Memory) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return []Result{}, nil
+	}
+	return rank(m.snapshot(), terms, limit), nil
+}
+
+func (m *Memory) Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error) {
+	return rankSuggestions(m.snapshot(), prefix, limit), nil
+}
+
+func (m *Memory) Index(ctx context.Context, product models.Product) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.products[product.ID] = product
+	return nil
+}
+
+func (m *Memory) Remove(ctx context.Context, id primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.products, id)
+	return nil
+}
+
+func (m *Memory) snapshot() []models.Product {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	products := make([]models.Product, 0, len(m.products))
+	for _, p := range m.products {
+		products = append(products, p)
+	}
+	return products
+}
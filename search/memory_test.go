@@ -0,0 +1,103 @@
+package search
+
+import (
+	"context"
+	"ecommerce/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestMemory(t *testing.T, products ...models.Product) *Memory {
+	t.Helper()
+	m := NewMemory()
+	for _, p := range products {
+		p.ID = primitive.NewObjectID()
+		if err := m.Index(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestMemorySearch(t *testing.T) {
+	m := newTestMemory(t,
+		models.Product{Name: "Running Shoe", Description: "Lightweight shoe for running"},
+		models.Product{Name: "Shoe Rack", Description: "Wooden rack"},
+		models.Product{Name: "Sock", Description: "Goes in a shoe"},
+		models.Product{Name: "Hat", Description: "Keeps you warm"},
+	)
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"name matches outrank description matches", "shoe", 10, []string{"Running Shoe", "Shoe Rack", "Sock"}},
+		{"typo", "shoo", 10, []string{"Running Shoe", "Shoe Rack", "Sock"}},
+		{"limit", "shoe", 2, []string{"Running Shoe", "Shoe Rack"}},
+		{"more terms rank higher", "wooden rack shoe", 10, []string{"Shoe Rack", "Running Shoe", "Sock"}},
+		{"no match", "umbrella", 10, []string{}},
+		{"empty query", "  ", 10, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := m.Search(context.Background(), tt.query, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(results))
+			for _, r := range results {
+				got = append(got, r.Product.Name)
+			}
+			assertNames(t, got, tt.want)
+		})
+	}
+}
+
+func TestMemorySuggest(t *testing.T) {
+	m := newTestMemory(t,
+		models.Product{Name: "Shoe Rack"},
+		models.Product{Name: "Running Shoe"},
+		models.Product{Name: "Shoelace"},
+		models.Product{Name: "Sock"},
+	)
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"leading matches first, shorter first", "sho", 10, []string{"Shoelace", "Shoe Rack", "Running Shoe"}},
+		{"limit", "sho", 1, []string{"Shoelace"}},
+		{"earlier words must match whole", "running sh", 10, []string{"Running Shoe"}},
+		{"no match", "hat", 10, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions, err := m.Suggest(context.Background(), tt.prefix, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(suggestions))
+			for _, s := range suggestions {
+				got = append(got, s.Name)
+			}
+			assertNames(t, got, tt.want)
+		})
+	}
+}
+
+func assertNames(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package search
+
+import (
+	"context"
+	"ecommerce/models"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fuzzyCandidates caps how many products the typo fallback scores.
+const fuzzyCandidates = 500
+
+// Mongo searches the product text index on name and description. Mongo
+// keeps the index up to date, so Index and Remove do nothing.
+type Mongo struct {
+	coll *mongo.Collection
+}
+
+func NewMongo(coll *mongo.Collection) *Mongo {
+	return &Mongo{coll: coll}
+}
+
+func (m *Mongo) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return []Result{}, nil
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(int64(limit))
+	cursor, err := m.coll.Find(ctx, bson.M{"$text": bson.M{"$search": query}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []struct {
+		models.Product `bson:",inline"`
+		Score          float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(hits))
+	seen := make(map[primitive.ObjectID]bool, len(hits))
+	for _, h := range hits {
+		results = append(results, Result{Product: h.Product, Score: h.Score, Highlights: highlights(h.Product, terms)})
+		seen[h.ID] = true
+	}
+	if len(results) == limit {
+		return results, nil
+	}
+
+	// The text index misses products that only match with a typo, so fill
+	// the remaining slots with fuzzy matches. Text scores and fuzzy scores
+	// are not comparable, so the fuzzy ones always come after.
+	fuzzy, err := m.fuzzySearch(ctx, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range fuzzy {
+		if len(results) == limit {
+			break
+		}
+		if !seen[r.Product.ID] {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// fuzzySearch handles queries with typos, which the text index cannot
+// match. It loads products with a word sharing the first two letters of a
+// query term and ranks them in process.
+func (m *Mongo) fuzzySearch(ctx context.Context, terms []string, limit int) ([]Result, error) {
+	var prefixes []string
+	for _, t := range terms {
+		if r := []rune(t); len(r) >= 4 {
+			prefixes = append(prefixes, regexp.QuoteMeta(string(r[:2])))
+		}
+	}
+	if len(prefixes) == 0 {
+		return []Result{}, nil
+	}
+
+	pattern := primitive.Regex{Pattern: `\b(` + strings.Join(prefixes, "|") + `)`, Options: "i"}
+	filter := bson.M{"$or": bson.A{bson.M{"name": pattern}, bson.M{"description": pattern}}}
+	cursor, err := m.coll.Find(ctx, filter, options.Find().SetLimit(fuzzyCandidates))
+	if err != nil {
+		return nil, err
+	}
+
+	var products []models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return rank(products, terms, limit), nil
+}
+
+func (m *Mongo) Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error) {
+	terms := tokenize(prefix)
+	if len(terms) == 0 {
+		return []Suggestion{}, nil
+	}
+
+	// Narrow down by the last, partly typed word and let rankSuggestions
+	// check the rest.
+	pattern := primitive.Regex{Pattern: `\b` + regexp.QuoteMeta(terms[len(terms)-1]), Options: "i"}
+	opts := options.Find().
+		SetProjection(bson.M{"name": 1}).
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetLimit(int64(limit * 5))
+	cursor, err := m.coll.Find(ctx, bson.M{"name": pattern}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return rankSuggestions(products, prefix, limit), nil
+}
+
+func (m *Mongo) Index(ctx context.Context, product models.Product) error {
+	return nil
+}
+
+func (m *Mongo) Remove(ctx context.Context, id primitive.ObjectID) error {
+	return nil
+}
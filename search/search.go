@@ -0,0 +1,76 @@
+// Package search finds products by name and description. The SearchIndex
+// interface has a Mongo implementation backed by a text index and an
+// in-process one that keeps every product in memory.
+package search
+
+import (
+	"context"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Result is a product matching a query. Highlights holds the matched
+// fields with each matching word wrapped in <em>; the rest of the text is
+// HTML-escaped.
+type Result struct {
+	Product    models.Product    `json:"product"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// Suggestion is an autocomplete entry.
+type Suggestion struct {
+	ID   primitive.ObjectID `json:"id"`
+	Name string             `json:"name"`
+}
+
+type SearchIndex interface {
+	// Search returns up to limit products ranked by relevance.
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+	// Suggest returns up to limit products whose name has a word starting
+	// with prefix.
+	Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error)
+	// Index adds or replaces a product.
+	Index(ctx context.Context, product models.Product) error
+	// Remove drops a product.
+	Remove(ctx context.Context, id primitive.ObjectID) error
+}
+
+var Default SearchIndex = NewMemory()
+
+// Init selects the index with SEARCH_BACKEND: "mongo" (default) or
+// "memory", which loads the catalog at startup.
+func Init() {
+	switch backend := config.GetEnv("SEARCH_BACKEND", "mongo"); backend {
+	case "memory":
+		m := NewMemory()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		cursor, err := database.ProductCollection.Find(ctx, bson.M{})
+		if err == nil {
+			var products []models.Product
+			if err = cursor.All(ctx, &products); err == nil {
+				for _, p := range products {
+					_ = m.Index(ctx, p)
+				}
+			}
+		}
+		if err != nil {
+			log.Println("⚠️  Failed to load products into search index:", err)
+		}
+		Default = m
+	default:
+		if backend != "mongo" {
+			log.Printf("⚠️  Unknown SEARCH_BACKEND %q, using mongo", backend)
+		}
+		Default = NewMongo(database.ProductCollection)
+	}
+}
@@ -0,0 +1,68 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed "12345678901234567890" from RFC 6238
+// Appendix B, base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCodeAt checks the RFC 6238 SHA1 test vectors, truncated to the last
+// six of their eight digits.
+func TestCodeAt(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("CodeAt(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	issued := time.Unix(1111111109, 0)
+	step := Step(issued)
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		skew int64
+		ok   bool
+	}{
+		{"same step", "081804", issued, 1, true},
+		{"spaces", " 081 804 ", issued, 1, true},
+		{"one step later", "081804", issued.Add(Period * time.Second), 1, true},
+		{"one step earlier", "081804", issued.Add(-Period * time.Second), 1, true},
+		{"two steps later", "081804", issued.Add(2 * Period * time.Second), 1, false},
+		{"outside window without skew", "081804", issued.Add(Period * time.Second), 0, false},
+		{"wrong code", "081805", issued, 1, false},
+		{"eight digits", "07081804", issued, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Validate(rfcSecret, tt.code, tt.at, tt.skew)
+			if ok != tt.ok {
+				t.Fatalf("Validate ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got != step {
+				t.Errorf("Validate step = %d, want %d", got, step)
+			}
+		})
+	}
+}